@@ -0,0 +1,186 @@
+package qparser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// allowlist restricts the fields, sort columns, selected columns and limit of options,
+// as declared by the schema of the parser or by the tags of a filter struct.
+// Nil sortable or selectable columns accept every column, and a zero max limit accepts any limit.
+type allowlist struct {
+	fields     []SchemaField
+	sortable   []string
+	selectable []string
+	maxLimit   int
+}
+
+// schemaAllowlist returns the allowlist declared by the given schema.
+// Sorting and selecting are rejected when the schema declares no sortable or selectable columns.
+func schemaAllowlist(schema *Schema) *allowlist {
+	a := &allowlist{
+		fields:     schema.Fields,
+		sortable:   schema.Sortable,
+		selectable: schema.Selectable,
+		maxLimit:   schema.MaxLimit,
+	}
+
+	if a.sortable == nil {
+		a.sortable = []string{}
+	}
+
+	if a.selectable == nil {
+		a.selectable = []string{}
+	}
+
+	return a
+}
+
+// structAllowlist returns the allowlist declared by the "query" tags of the given filter struct, see Parser.ParseStruct.
+// Sorting and selecting are restricted to the "allow" option of the "sort" and "fields" tags,
+// unrestricted without one, and rejected when the struct has no such tag.
+func structAllowlist(filter interface{}) (*allowlist, error) {
+	t := reflect.TypeOf(filter)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("filter must be a struct or a pointer to a struct")
+	}
+
+	a := &allowlist{sortable: []string{}, selectable: []string{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if !field.IsExported() || field.Tag.Get("query") == "" || field.Tag.Get("query") == "-" {
+			continue
+		}
+
+		tag, tagOptions := parseTag(field.Tag.Get("query"))
+
+		var allowed []string
+		if allow, ok := tagOptions["allow"]; ok {
+			allowed = strings.Split(allow, "|")
+		}
+
+		switch tag {
+		case "limit", "offset", "after", "include", "modified_since":
+		case "sort":
+			a.sortable = allowed
+		case "fields":
+			a.selectable = allowed
+		default:
+			a.fields = append(a.fields, SchemaField{
+				Name:  tag,
+				Class: tagOptions["class"],
+				Table: tagOptions["table"],
+				Fold:  hasOption(tagOptions, "fold"),
+			})
+		}
+	}
+
+	return a, nil
+}
+
+// field checks that the given field of a serialized document is declared by the allowlist with its operator,
+// and takes its classification and case folding from the declaration, so a document cannot change them.
+// Unknown fields are rejected suggesting the declared fields the given role policy allows filtering on.
+func (a *allowlist) field(field *Field, policy *FieldPolicy) error {
+	names := make([]string, 0, len(a.fields))
+
+	for _, declared := range a.fields {
+		if policy.allowsField(declared.column()) {
+			names = append(names, declared.Name)
+		}
+
+		if declared.column() != field.Name || declared.Table != field.Table {
+			continue
+		}
+
+		if !declared.allows(field.Operator) {
+			return declared.operatorNotAllowed(field.Operator)
+		}
+
+		field.Class = declared.Class
+		field.Fold = declared.Fold
+
+		return nil
+	}
+
+	return reject(ReasonUnknownField, field.Name, "", names, fmt.Sprintf("unknown field %s", field.Name))
+}
+
+// conform checks that the options stay within the given allowlist once parsed:
+// the names and tables of the fields must be plain identifiers, the sort and selected columns must be allowed,
+// and the limit must not exceed the max limit, which is also used when no limit is set.
+// The columns are also restricted to those allowed for the role of the options, see WithRolePolicy.
+// A nil allowlist only checks the identifiers.
+func (o *Options) conform(a *allowlist) error {
+	for _, field := range o.fields {
+		if !identifierRegexp.MatchString(field.Name) {
+			return fmt.Errorf("bad field %s", field.Name)
+		}
+
+		if field.Table != "" && !identifierRegexp.MatchString(field.Table) {
+			return fmt.Errorf("bad table %s of field %s", field.Table, field.Name)
+		}
+	}
+
+	var sortable, selectable []string
+	if a != nil {
+		sortable, selectable = a.sortable, a.selectable
+	}
+
+	sortable, err := o.sortable(sortable)
+	if err != nil {
+		return err
+	}
+
+	selectable, err = o.selectable(selectable)
+	if err != nil {
+		return err
+	}
+
+	for _, sort := range o.sorts {
+		if !identifierRegexp.MatchString(sort.Column) {
+			return fmt.Errorf("bad sort column %s", sort.Column)
+		}
+
+		if sortable != nil && !contains(sortable, sort.Column) {
+			return reject(ReasonSortNotAllowed, sort.Column, "", sortable, fmt.Sprintf("sorting by %s is not allowed", sort.Column))
+		}
+	}
+
+	for _, column := range o.selects {
+		if !identifierRegexp.MatchString(column) {
+			return fmt.Errorf("bad field %s", column)
+		}
+
+		if selectable != nil && !contains(selectable, column) {
+			return reject(ReasonSelectNotAllowed, column, "", selectable, fmt.Sprintf("selecting %s is not allowed", column))
+		}
+	}
+
+	if o.limit < 0 {
+		return fmt.Errorf("limit must be greater than 0")
+	}
+
+	if o.offset < 0 {
+		return fmt.Errorf("offset must be greater than 0")
+	}
+
+	if a != nil && a.maxLimit > 0 {
+		if o.limit > a.maxLimit {
+			return fmt.Errorf("limit must not exceed %d", a.maxLimit)
+		}
+
+		if o.limit == 0 {
+			o.limit = a.maxLimit
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package qparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// arrayCondition renders the "anyof" or "allof" operator of the given field as a condition
+// testing the elements of the JSON array stored in the column.
+// Postgres uses the ?| and ?& operators on a text array, which are supported by the default
+// GIN operator class on JSONB columns, unlike containment of a built JSON document.
+// Mysql uses JSON_OVERLAPS and JSON_CONTAINS with the elements encoded as a JSON array,
+// and sqlite counts the matching elements of json_each.
+func arrayCondition(dialect Dialect, column string, field *Field) condition {
+	args := make([]interface{}, 0, len(field.Values))
+	for _, value := range field.Values {
+		args = append(args, value)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+
+	switch dialect {
+	case DialectMySQL:
+		encoded, _ := json.Marshal(field.Values)
+
+		if field.Operator == sqlOperatorAnyOf {
+			return condition{query: fmt.Sprintf("JSON_OVERLAPS(%s, ?)", column), args: []interface{}{string(encoded)}}
+		}
+
+		return condition{query: fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), args: []interface{}{string(encoded)}}
+	case DialectSQLite:
+		if field.Operator == sqlOperatorAnyOf {
+			return condition{
+				query: fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE json_each.value IN (%s))", column, placeholders),
+				args:  args,
+			}
+		}
+
+		return condition{
+			query: fmt.Sprintf("(SELECT COUNT(DISTINCT json_each.value) FROM json_each(%s) WHERE json_each.value IN (%s)) = %d", column, placeholders, len(args)),
+			args:  args,
+		}
+	default:
+		return condition{
+			query: fmt.Sprintf("%s %s array[%s]", column, strings.ReplaceAll(field.Operator, "?", "??"), placeholders),
+			args:  args,
+		}
+	}
+}
+
+// escapedExpr is a condition whose query escapes literal question marks as "??".
+// It is built by binding every other "?" to the next argument.
+type escapedExpr condition
+
+// Build writes the query of the condition to the given builder.
+func (e escapedExpr) Build(builder clause.Builder) {
+	args := e.args
+
+	for i := 0; i < len(e.query); i++ {
+		if e.query[i] != '?' {
+			builder.WriteByte(e.query[i])
+			continue
+		}
+
+		if i+1 < len(e.query) && e.query[i+1] == '?' {
+			builder.WriteByte('?')
+			i++
+
+			continue
+		}
+
+		if len(args) > 0 {
+			builder.AddVar(builder, args[0])
+			args = args[1:]
+		}
+	}
+}
+
+// expression returns the condition as a GORM expression.
+// Conditions escaping literal question marks are built by escapedExpr,
+// every other condition is a plain expression.
+func (c condition) expression() clause.Expression {
+	if strings.Contains(c.query, "??") {
+		return escapedExpr(c)
+	}
+
+	return clause.Expr{SQL: c.query, Vars: c.args}
+}
+
+// unescape replaces the escaped question marks of the given query by literal ones.
+func unescape(query string) string {
+	return strings.ReplaceAll(query, "??", "?")
+}
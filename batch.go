@@ -0,0 +1,60 @@
+package qparser
+
+import (
+	"database/sql"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ParseStructs parses the given filter structs with the default parser.
+// See ParseStructsWith for the details.
+func ParseStructs[T any](items []T) ([]*Options, error) {
+	return ParseStructsWith(defaultParser, items)
+}
+
+// ParseStructsWith parses every given filter struct with the given parser,
+// returning the options in the same order as the items.
+// If any item fails to parse, an error naming the index of the item is returned.
+func ParseStructsWith[T any](parser *Parser, items []T) ([]*Options, error) {
+	options := make([]*Options, 0, len(items))
+
+	for i, item := range items {
+		opt, err := parser.ParseStruct(item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+
+		options = append(options, opt)
+	}
+
+	return options, nil
+}
+
+// ApplyBatch executes a query for every given options against the model T in a single read only transaction,
+// so bulk lookup endpoints share one connection. The transaction is opened with the repeatable read isolation level
+// so every query sees the same snapshot, even on databases defaulting to read committed like postgres.
+// The results are returned in the same order as the options.
+// If any query fails, the transaction is rolled back and the error is returned.
+func ApplyBatch[T any](tx *gorm.DB, options []*Options) ([][]T, error) {
+	results := make([][]T, 0, len(options))
+
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		for i, opt := range options {
+			rows := make([]T, 0)
+
+			if err := opt.Apply(tx.Model(new(T))).Find(&rows).Error; err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+
+			results = append(results, rows)
+		}
+
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
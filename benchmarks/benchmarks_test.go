@@ -0,0 +1,219 @@
+package benchmarks
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/0x16F/qparser"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// size is the number of filters of a benchmarked query.
+type size struct {
+	name    string
+	filters int
+}
+
+// sizes are the filter set sizes every operation is benchmarked with.
+var sizes = []size{
+	{name: "small", filters: 1},
+	{name: "medium", filters: 10},
+	{name: "large", filters: 50},
+}
+
+// row is the model the benchmarked queries are applied to.
+type row struct {
+	ID uint
+}
+
+// BenchmarkParseStruct benchmarks parsing a struct with one string field per filter.
+func BenchmarkParseStruct(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			fields := make([]reflect.StructField, 0, size.filters)
+			for i := 0; i < size.filters; i++ {
+				fields = append(fields, reflect.StructField{
+					Name: fmt.Sprintf("F%d", i),
+					Type: reflect.TypeOf(""),
+					Tag:  reflect.StructTag(fmt.Sprintf(`query:"f%d"`, i)),
+				})
+			}
+
+			data := reflect.New(reflect.StructOf(fields)).Elem()
+			for i := 0; i < size.filters; i++ {
+				data.Field(i).SetString(query(i))
+			}
+
+			parser := qparser.NewParser()
+			value := data.Interface()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.ParseStruct(value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseValues benchmarks parsing query values against a schema.
+func BenchmarkParseValues(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			parser, values := parserAndValues(size)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.ParseValues(values); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkApply benchmarks applying options to a transaction and building the query in dry run mode.
+func BenchmarkApply(b *testing.B) {
+	db, err := gorm.Open(dialector{}, &gorm.Config{DryRun: true, Logger: logger.Discard})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			options := options(b, size)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				var rows []row
+
+				if err := options.Apply(db.Model(&row{})).Find(&rows).Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkToSQL benchmarks rendering options as SQL.
+func BenchmarkToSQL(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			options := options(b, size)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, _, err := options.ToSQL(qparser.DialectPostgres); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// options returns parsed options with the given number of filters.
+func options(b *testing.B, size size) *qparser.Options {
+	parser, values := parserAndValues(size)
+
+	options, err := parser.ParseValues(values)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return options
+}
+
+// parserAndValues returns a parser with a schema declaring the given number of fields
+// and query values filtering on each of them.
+func parserAndValues(size size) (*qparser.Parser, url.Values) {
+	s := &qparser.Schema{Sortable: []string{"f0"}}
+	values := url.Values{"sort": {"-f0"}, "limit": {"20"}}
+
+	for i := 0; i < size.filters; i++ {
+		name := fmt.Sprintf("f%d", i)
+
+		s.Fields = append(s.Fields, qparser.SchemaField{Name: name})
+		values.Set(name, query(i))
+	}
+
+	return qparser.NewParser(qparser.WithSchema(s)), values
+}
+
+// query returns the query of the i-th filter, cycling through representative operators.
+func query(i int) string {
+	switch i % 4 {
+	case 0:
+		return "eq:active"
+	case 1:
+		return "gte:18"
+	case 2:
+		return "like:bob"
+	default:
+		return "rng:2020-01-01 to 2020-12-31"
+	}
+}
+
+// dialector is a minimal GORM dialector building postgres-like SQL without a database,
+// so Apply can be benchmarked in dry run mode without a driver.
+type dialector struct{}
+
+// Name returns the name of the dialect the SQL is built for.
+func (dialector) Name() string {
+	return "postgres"
+}
+
+// Initialize registers the default callbacks building the queries.
+func (dialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+
+	return nil
+}
+
+// Migrator returns no migrator, the benchmarks never migrate.
+func (dialector) Migrator(*gorm.DB) gorm.Migrator {
+	return nil
+}
+
+// DataTypeOf returns no data type, the benchmarks never migrate.
+func (dialector) DataTypeOf(*schema.Field) string {
+	return ""
+}
+
+// DefaultValueOf returns the DEFAULT keyword.
+func (dialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+// BindVarTo writes a numbered postgres placeholder.
+func (dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	writer.WriteByte('$')
+	writer.WriteString(strconv.Itoa(len(stmt.Vars)))
+}
+
+// QuoteTo writes the given identifier in double quotes.
+func (dialector) QuoteTo(writer clause.Writer, str string) {
+	writer.WriteByte('"')
+	writer.WriteString(str)
+	writer.WriteByte('"')
+}
+
+// Explain interpolates the given arguments into the SQL for logs.
+func (dialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
@@ -0,0 +1,22 @@
+// Package benchmarks measures the hot path of qparser, parsing and rendering, across filter sets of several sizes.
+//
+// The benchmarks are regular go test benchmarks of ParseStruct, ParseValues, Apply and ToSQL,
+// each one run with 1, 10 and 50 filters:
+//
+//	go test -run '^$' -bench . -count 10 ./benchmarks > new.txt
+//
+// Regressions are gated against a baseline recorded on the same machine from the base revision,
+// checked out in a separate worktree so the working tree is left alone:
+//
+//	git worktree add ../qparser-base main
+//	(cd ../qparser-base && go test -run '^$' -bench . -count 10 ./benchmarks) > old.txt
+//	git worktree remove ../qparser-base
+//	qparser bench --threshold=5 --alpha=0.05 old.txt new.txt
+//
+// The budget of every benchmark is relative to the baseline instead of an absolute time per operation,
+// which depends on the hardware: the time and the allocations per operation may not regress by more than 5%
+// with a difference significant at the level of 0.05 according to the statistics of benchstat,
+// the defaults of the bench command of cmd/qparser.
+//
+// The package has no API, it only holds the benchmarks.
+package benchmarks
@@ -0,0 +1,233 @@
+package qparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// DocumentLimits restricts the filter documents parsed by ParseJSON.
+// MaxBytes is the maximum size of a document, 1 MiB by default.
+// MaxFilters is the maximum number of filter values of a document, 100 by default.
+// MaxValueLength is the maximum length of a single value, 4096 bytes by default.
+type DocumentLimits struct {
+	MaxBytes       int64
+	MaxFilters     int
+	MaxValueLength int
+}
+
+// WithDocumentLimits configures the limits enforced while parsing filter documents, see ParseJSON.
+// Zero limits keep their default.
+func WithDocumentLimits(limits DocumentLimits) ParserOption {
+	return func(c *config) {
+		c.documentLimits = limits
+	}
+}
+
+// withDefaults returns the limits with their defaults applied.
+func (l DocumentLimits) withDefaults() DocumentLimits {
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = 1 << 20
+	}
+
+	if l.MaxFilters <= 0 {
+		l.MaxFilters = 100
+	}
+
+	if l.MaxValueLength <= 0 {
+		l.MaxValueLength = 4096
+	}
+
+	return l
+}
+
+// ParseJSON parses the filter document read from r without a user.
+// See ParseJSONContext for the details.
+func (p *Parser) ParseJSON(r io.Reader) (*Options, error) {
+	return p.ParseJSONContext(context.Background(), r)
+}
+
+// ParseJSONContext parses a filter document read from r, e.g. the body of a POST request
+// for filters too long for a query string, according to the schema of the parser.
+// The document is a JSON object using the parameters of ParseValuesContext as keys,
+// with a string, a number or an array of them as value, e.g.
+//
+//	{"name": "like:bob", "age": ["gte:18", "lt:65"], "sort": "-age", "limit": 20}
+//
+// The document is decoded token by token, and rejected as soon as it exceeds the limits of the parser,
+// see WithDocumentLimits, or uses a field missing from the schema, so a large hostile document is never fully read.
+// The values are then parsed like query values, see ParseValuesContext.
+func (p *Parser) ParseJSONContext(ctx context.Context, r io.Reader) (*Options, error) {
+	cfg := p.config.Load()
+
+	if cfg.schema == nil {
+		return nil, fmt.Errorf("parser has no schema")
+	}
+
+	limits := cfg.documentLimits.withDefaults()
+
+	known := make(map[string]struct{}, len(cfg.schema.Fields))
+	for _, schemaField := range cfg.schema.Fields {
+		known[schemaField.Name] = struct{}{}
+	}
+
+	decoder := json.NewDecoder(&limitedReader{reader: r, remaining: limits.MaxBytes, limit: limits.MaxBytes})
+	decoder.UseNumber()
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	values := make(url.Values)
+	filters := 0
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, documentError(err)
+		}
+
+		name := cfg.canonicalName(token.(string))
+		if _, ok := known[name]; !ok && !reservedParameters[name] {
+			return nil, cfg.unknownField(RoleFromContext(ctx), name)
+		}
+
+		add := func(token json.Token) error {
+			value, ok := documentValue(token)
+			if !ok {
+				return fmt.Errorf("bad value of %s, use a string, a number or an array of them", name)
+			}
+
+			if len(value) > limits.MaxValueLength {
+				return fmt.Errorf("value of %s exceeds %d bytes", name, limits.MaxValueLength)
+			}
+
+			if !reservedParameters[name] {
+				if filters++; filters > limits.MaxFilters {
+					return fmt.Errorf("filter document has more than %d filters", limits.MaxFilters)
+				}
+			}
+
+			values.Add(name, value)
+
+			return nil
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, documentError(err)
+		}
+
+		if token != json.Delim('[') {
+			if err := add(token); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, documentError(err)
+			}
+
+			if err := add(token); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := expectDelim(decoder, ']'); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("bad filter document, unexpected data after the object")
+	}
+
+	return p.ParseValuesContext(ctx, values)
+}
+
+// documentValue returns the query value of a scalar token of a filter document.
+func documentValue(token json.Token) (string, bool) {
+	switch value := token.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	default:
+		return "", false
+	}
+}
+
+// expectDelim reads the next token of the decoder, which must be the given delimiter.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return documentError(err)
+	}
+
+	if token != delim {
+		return fmt.Errorf("bad filter document, expected %s", delim)
+	}
+
+	return nil
+}
+
+// documentError wraps an error decoding a filter document, keeping the error of a document too large as is.
+func documentError(err error) error {
+	var tooLarge *documentTooLargeError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return fmt.Errorf("bad filter document: %w", err)
+}
+
+// documentTooLargeError is returned by a limitedReader once more than its limit was read.
+type documentTooLargeError struct {
+	limit int64
+}
+
+func (e *documentTooLargeError) Error() string {
+	return fmt.Sprintf("filter document exceeds %d bytes", e.limit)
+}
+
+// limitedReader reads from a reader until more than limit bytes were read, and then fails.
+// Unlike io.LimitReader, exceeding the limit results in an error instead of a silently truncated document.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	limit     int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &documentTooLargeError{limit: l.limit}
+	}
+
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+
+	if l.remaining < 0 {
+		return n, &documentTooLargeError{limit: l.limit}
+	}
+
+	return n, err
+}
@@ -0,0 +1,274 @@
+package qparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default limits of the boolean tree of the conditions, see ConditionLimits.
+const (
+	defaultMaxDepth    = 4
+	defaultMaxBranches = 10
+	defaultMaxNodes    = 500
+)
+
+// ConditionLimits bounds the boolean tree the conditions of options are built as, so the generated SQL stays bounded.
+// Filters are combined with AND, the ranges of a "rng" filter with OR, the words of a "words" filter with AND,
+// and the exclusions of "hasnot" and "notin_sub" are negations.
+// The tree is normalized before it is measured and rendered, see node.normalize,
+// so nested nodes of the same kind are counted once, e.g. the words of a filter are combined with the other filters.
+// MaxDepth is the maximum nesting of AND, OR and NOT nodes, 4 by default, the AND of the filters being the first level.
+// MaxBranches is the maximum number of conditions a single filter combines with OR, 10 by default.
+// MaxNodes is the maximum number of nodes of the tree, conditions included, 500 by default.
+type ConditionLimits struct {
+	MaxDepth    int
+	MaxBranches int
+	MaxNodes    int
+}
+
+// WithConditionLimits configures the limits of the boolean tree of the conditions of parsed and deserialized options.
+// Options exceeding them are rejected with a RejectionError of reason ReasonTooDeep, ReasonTooManyBranches
+// or ReasonTooManyConditions. Zero limits keep their default.
+func WithConditionLimits(limits ConditionLimits) ParserOption {
+	return func(c *config) {
+		c.conditionLimits = limits
+	}
+}
+
+// withDefaults returns the limits with their defaults applied.
+func (l ConditionLimits) withDefaults() ConditionLimits {
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = defaultMaxDepth
+	}
+
+	if l.MaxBranches <= 0 {
+		l.MaxBranches = defaultMaxBranches
+	}
+
+	if l.MaxNodes <= 0 {
+		l.MaxNodes = defaultMaxNodes
+	}
+
+	return l
+}
+
+// nodeKind is the kind of a node of a boolean tree.
+type nodeKind int
+
+const (
+	nodeLeaf nodeKind = iota
+	nodeAnd
+	nodeOr
+	nodeNot
+)
+
+// node is a node of the boolean tree of the conditions of options.
+// Leaves hold a condition, and optionally its negated form, rendered when a NOT is pushed down to the leaf.
+// AND and OR nodes combine their children, and NOT nodes negate their only child.
+type node struct {
+	kind      nodeKind
+	condition condition
+	negation  *condition
+	children  []node
+}
+
+// leaf returns a leaf holding the given condition.
+// The condition is rendered as is among the conditions of its parent, so it must not combine conditions with OR.
+func leaf(c condition) node {
+	return node{kind: nodeLeaf, condition: c}
+}
+
+// negatableLeaf returns a leaf holding the given condition, rendered as the given negation when it is negated.
+func negatableLeaf(c, negation condition) node {
+	return node{kind: nodeLeaf, condition: c, negation: &negation}
+}
+
+// and returns the node combining the given children with AND.
+func and(children ...node) node {
+	return node{kind: nodeAnd, children: children}
+}
+
+// or returns the node combining the given children with OR.
+func or(children ...node) node {
+	return node{kind: nodeOr, children: children}
+}
+
+// not returns the node negating the given child.
+func not(child node) node {
+	return node{kind: nodeNot, children: []node{child}}
+}
+
+// normalize returns the tree equivalent to the node in normal form: NOT is pushed down to the leaves
+// with De Morgan's laws, double negations are removed, the children of the same kind as their parent
+// are flattened into it, and AND and OR nodes with a single child are replaced by it.
+func (n node) normalize() node {
+	switch n.kind {
+	case nodeNot:
+		return n.children[0].negate()
+	case nodeAnd, nodeOr:
+		children := make([]node, 0, len(n.children))
+
+		for _, child := range n.children {
+			child = child.normalize()
+
+			if child.kind == n.kind {
+				children = append(children, child.children...)
+				continue
+			}
+
+			children = append(children, child)
+		}
+
+		if len(children) == 1 {
+			return children[0]
+		}
+
+		return node{kind: n.kind, children: children}
+	default:
+		return n
+	}
+}
+
+// negate returns the normalized negation of the node.
+// Leaves without a negated form are kept under a NOT node.
+func (n node) negate() node {
+	switch n.kind {
+	case nodeNot:
+		return n.children[0].normalize()
+	case nodeAnd, nodeOr:
+		kind := nodeOr
+		if n.kind == nodeOr {
+			kind = nodeAnd
+		}
+
+		children := make([]node, 0, len(n.children))
+		for _, child := range n.children {
+			children = append(children, not(child))
+		}
+
+		return node{kind: kind, children: children}.normalize()
+	default:
+		if n.negation != nil {
+			return negatableLeaf(*n.negation, n.condition)
+		}
+
+		return not(n)
+	}
+}
+
+// depth returns the nesting of the AND, OR and NOT nodes of the tree, 0 for a leaf.
+func (n node) depth() int {
+	depth := 0
+
+	for _, child := range n.children {
+		depth = max(depth, child.depth())
+	}
+
+	if n.kind == nodeLeaf {
+		return depth
+	}
+
+	return depth + 1
+}
+
+// size returns the number of nodes of the tree, including the node itself.
+func (n node) size() int {
+	size := 1
+
+	for _, child := range n.children {
+		size += child.size()
+	}
+
+	return size
+}
+
+// branches returns the greatest number of children of the OR nodes of the tree.
+func (n node) branches() int {
+	branches := 0
+	if n.kind == nodeOr {
+		branches = len(n.children)
+	}
+
+	for _, child := range n.children {
+		branches = max(branches, child.branches())
+	}
+
+	return branches
+}
+
+// render renders the node as a single condition nested in a node of the given kind,
+// wrapping AND and OR nodes of another kind in parentheses so the precedence is explicit.
+func (n node) render(parent nodeKind) condition {
+	switch n.kind {
+	case nodeLeaf:
+		return n.condition
+	case nodeNot:
+		c := n.children[0].render(nodeNot)
+
+		return condition{query: "NOT (" + c.query + ")", args: c.args}
+	}
+
+	separator := " AND "
+	if n.kind == nodeOr {
+		separator = " OR "
+	}
+
+	queries := make([]string, 0, len(n.children))
+	args := make([]interface{}, 0, len(n.children))
+
+	for _, child := range n.children {
+		c := child.render(n.kind)
+
+		queries = append(queries, c.query)
+		args = append(args, c.args...)
+	}
+
+	query := strings.Join(queries, separator)
+	if parent != n.kind {
+		query = "(" + query + ")"
+	}
+
+	return condition{query: query, args: args}
+}
+
+// conditionTree returns the normalized boolean tree of the fields of the options for the given dialect,
+// combining the tree of every field with AND, see fieldNode.
+func (o *Options) conditionTree(dialect Dialect) node {
+	children := make([]node, 0, len(o.fields))
+
+	for _, field := range o.fields {
+		children = append(children, o.fieldNode(dialect, field))
+	}
+
+	return and(children...).normalize()
+}
+
+// checkConditions checks that the boolean tree of the conditions of the options stays within the limits of the parser,
+// see WithConditionLimits.
+func (o *Options) checkConditions() error {
+	var limits ConditionLimits
+	if o.config != nil {
+		limits = o.config.conditionLimits
+	}
+
+	limits = limits.withDefaults()
+
+	for _, field := range o.fields {
+		if branches := o.fieldNode("", field).normalize().branches(); branches > limits.MaxBranches {
+			return reject(ReasonTooManyBranches, revertOperator(field.Operator), field.Name, nil,
+				fmt.Sprintf("%s on %s combines %d conditions with OR, at most %d are allowed", revertOperator(field.Operator), field.Name, branches, limits.MaxBranches))
+		}
+	}
+
+	tree := o.conditionTree("")
+
+	if depth := tree.depth(); depth > limits.MaxDepth {
+		return reject(ReasonTooDeep, "", "", nil, fmt.Sprintf("conditions are nested %d levels deep, at most %d are allowed", depth, limits.MaxDepth))
+	}
+
+	if size := tree.size(); size > limits.MaxNodes {
+		return reject(ReasonTooManyConditions, "", "", nil, fmt.Sprintf("conditions have %d nodes, at most %d are allowed", size, limits.MaxNodes))
+	}
+
+	return nil
+}
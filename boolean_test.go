@@ -0,0 +1,134 @@
+package qparser
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	a := leaf(condition{query: "a = ?"})
+	b := leaf(condition{query: "b = ?"})
+	c := leaf(condition{query: "c = ?"})
+	exists := negatableLeaf(condition{query: "EXISTS (q)"}, condition{query: "NOT EXISTS (q)"})
+
+	tests := []struct {
+		name string
+		tree node
+		want string
+	}{
+		{name: "flatten and", tree: and(a, and(b, c)), want: "a = ? AND b = ? AND c = ?"},
+		{name: "flatten or", tree: or(or(a, b), c), want: "(a = ? OR b = ? OR c = ?)"},
+		{name: "nested or", tree: and(a, or(b, c)), want: "a = ? AND (b = ? OR c = ?)"},
+		{name: "single child", tree: and(or(a)), want: "a = ?"},
+		{name: "de morgan and", tree: not(and(a, b)), want: "(NOT (a = ?) OR NOT (b = ?))"},
+		{name: "de morgan or", tree: and(c, not(or(a, b))), want: "c = ? AND NOT (a = ?) AND NOT (b = ?)"},
+		{name: "double negation", tree: not(not(a)), want: "a = ?"},
+		{name: "negated leaf", tree: not(exists), want: "NOT EXISTS (q)"},
+		{name: "negated negation", tree: or(a, not(not(exists))), want: "(a = ? OR EXISTS (q))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.tree.normalize().render(nodeAnd).query
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionTreeSQL(t *testing.T) {
+	p := NewParser(
+		WithRelation("orders", Relation{Table: "orders", ForeignKey: "user_id", References: "users.id"}),
+		WithSchema(&Schema{
+			Fields: []SchemaField{
+				{Name: "name"},
+				{Name: "age"},
+				{Name: "relations"},
+			},
+		}),
+	)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "name=words:john smith", want: "WHERE name ILIKE ? AND name ILIKE ?"},
+		{query: "name=words:john&age=rng:1 to 2|rng:5 to 6", want: "WHERE name ILIKE ? AND (age BETWEEN ? AND ? OR age BETWEEN ? AND ?)"},
+		{query: "age=rng:1 to 2", want: "WHERE age BETWEEN ? AND ?"},
+		{query: "relations=hasnot:orders", want: "WHERE NOT EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opt, err := p.ParseValues(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query, _, err := opt.ToSQL(DialectPostgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if query != tt.want {
+				t.Errorf("got %q, want %q", query, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionLimits(t *testing.T) {
+	schema := &Schema{Fields: []SchemaField{{Name: "name"}, {Name: "age"}}}
+
+	tests := []struct {
+		name   string
+		limits ConditionLimits
+		query  string
+		reason RejectionReason
+	}{
+		{name: "default branches", query: "age=" + strings.Repeat("rng:1 to 2|", 10) + "rng:1 to 2", reason: ReasonTooManyBranches},
+		{name: "branches", limits: ConditionLimits{MaxBranches: 2}, query: "age=rng:1 to 2|rng:3 to 4|rng:5 to 6", reason: ReasonTooManyBranches},
+		{name: "within branches", limits: ConditionLimits{MaxBranches: 2}, query: "age=rng:1 to 2|rng:3 to 4"},
+		{name: "depth", limits: ConditionLimits{MaxDepth: 1}, query: "name=eq:john&age=rng:1 to 2|rng:3 to 4", reason: ReasonTooDeep},
+		{name: "flattened depth", limits: ConditionLimits{MaxDepth: 1}, query: "name=words:john smith&age=eq:1"},
+		{name: "nodes", limits: ConditionLimits{MaxNodes: 3}, query: "name=words:a b c", reason: ReasonTooManyConditions},
+		{name: "within nodes", limits: ConditionLimits{MaxNodes: 3}, query: "name=words:a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(WithSchema(schema), WithConditionLimits(tt.limits))
+
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = p.ParseValues(values)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			var rejection *RejectionError
+			if !errors.As(err, &rejection) {
+				t.Fatalf("expected a rejection, got %v", err)
+			}
+
+			if rejection.Reason != tt.reason {
+				t.Errorf("got reason %s, want %s", rejection.Reason, tt.reason)
+			}
+		})
+	}
+}
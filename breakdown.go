@@ -0,0 +1,99 @@
+package qparser
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// WithBreakdownColumns configures the columns rows may be counted by with Options.Breakdown and Options.DistinctValues.
+// Columns not listed are rejected, so clients cannot group by unindexed or sensitive columns.
+func WithBreakdownColumns(columns ...string) ParserOption {
+	return func(c *config) {
+		c.breakdownColumns = append(c.breakdownColumns, columns...)
+	}
+}
+
+// Breakdown returns the number of rows matching the filters of the options for every value of the given column,
+// ignoring sorting and pagination, e.g. for "results by status" summary bars above a filtered table.
+// The column must be allowed with WithBreakdownColumns. NULL values are counted under the empty string.
+// The transaction must have a model or table set.
+func (o *Options) Breakdown(tx *gorm.DB, column string) (map[string]int64, error) {
+	groups, err := o.groupCounts(tx, column, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := make(map[string]int64, len(groups))
+
+	for _, group := range groups {
+		breakdown[group.Value] += group.Count
+	}
+
+	return breakdown, nil
+}
+
+// ValueCount is a distinct value of a column and the number of rows holding it, see Options.DistinctValues.
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// DistinctValues returns up to limit distinct values of the given column among the rows matching the filters
+// of the options, the most frequent first, with the number of rows holding them,
+// e.g. for dropdown filter options adapting to the visible rows.
+// Sorting and pagination are ignored, and values with the same count are ordered by value.
+// The column must be allowed with WithBreakdownColumns. NULL values are returned as the empty string.
+// The transaction must have a model or table set.
+func (o *Options) DistinctValues(tx *gorm.DB, column string, limit int) ([]ValueCount, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be greater than 0")
+	}
+
+	return o.groupCounts(tx, column, limit)
+}
+
+// groupCounts counts the rows matching the filters of the options per value of the given allowed column,
+// the most frequent values first, returning at most limit values if it is positive.
+func (o *Options) groupCounts(tx *gorm.DB, column string, limit int) ([]ValueCount, error) {
+	if o.config == nil || !contains(o.config.breakdownColumns, column) {
+		return nil, fmt.Errorf("breakdown by %s is not allowed", column)
+	}
+
+	if !identifierRegexp.MatchString(column) {
+		return nil, fmt.Errorf("bad breakdown column %s", column)
+	}
+
+	column = o.viewColumn(column)
+
+	var groups []struct {
+		Value *string
+		Count int64
+	}
+
+	query := o.filter(tx).
+		Select(fmt.Sprintf("%s AS value, COUNT(*) AS count", column)).
+		Group(column).
+		Order(fmt.Sprintf("COUNT(*) DESC, %s", column))
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Scan(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make([]ValueCount, 0, len(groups))
+
+	for _, group := range groups {
+		value := ""
+		if group.Value != nil {
+			value = *group.Value
+		}
+
+		counts = append(counts, ValueCount{Value: value, Count: group.Count})
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,359 @@
+package qparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var cacheSchemas sync.Map
+
+// Cache stores serialized query results tagged with the tables they were read from,
+// so every result depending on a table can be invalidated at once when its rows change.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, tags []string)
+	InvalidateTag(tag string)
+}
+
+// MemoryCache is an in-memory Cache expiring its entries after a fixed TTL.
+// Expired entries are swept when values are stored, at most once per TTL,
+// so entries which are never read again do not accumulate.
+// It is safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]memoryCacheEntry
+	tags    map[string]map[string]struct{}
+	sweep   time.Time
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	tags    []string
+	expires time.Time
+}
+
+// NewMemoryCache creates a new MemoryCache expiring its entries after the given TTL.
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		ttl:     ttl,
+		entries: make(map[string]memoryCacheEntry),
+		tags:    make(map[string]map[string]struct{}),
+		sweep:   time.Now(),
+	}
+}
+
+// Get returns the value stored under the given key, if it has not expired.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expires) {
+		c.remove(key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// Set stores the value under the given key and tags it with the given tags,
+// replacing the value and the tags previously stored under the key.
+func (c *MemoryCache) Set(key string, value []byte, tags []string) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.sweep) >= c.ttl {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				c.remove(k)
+			}
+		}
+
+		c.sweep = now
+	}
+
+	c.remove(key)
+	c.entries[key] = memoryCacheEntry{value: value, tags: append([]string(nil), tags...), expires: now.Add(c.ttl)}
+
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+
+		c.tags[tag][key] = struct{}{}
+	}
+}
+
+// InvalidateTag removes every value tagged with the given tag.
+func (c *MemoryCache) InvalidateTag(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.tags[tag] {
+		c.remove(key)
+	}
+
+	delete(c.tags, tag)
+}
+
+// remove deletes the entry stored under the given key and unlinks it from its tags,
+// dropping the tags left without entries. The mutex must be held.
+func (c *MemoryCache) remove(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	delete(c.entries, key)
+
+	for _, tag := range entry.tags {
+		delete(c.tags[tag], key)
+
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// CachedFind executes the query built by Apply into dest, unless its result is already cached.
+// Results are stored as JSON under the given key combined with the queried table, the hash of the options
+// and the user they were parsed for, see ContextWithUser, so per-user scopes and subquery arguments
+// never share results between callers. They are tagged with every table they are read from, see cacheTags,
+// so an Invalidator can drop them on writes.
+// The key usually names the endpoint.
+// The transaction must have a model or table set, and a model when the options include associations.
+func (o *Options) CachedFind(cache Cache, key string, tx *gorm.DB, dest interface{}) error {
+	table, err := tableName(tx)
+	if err != nil {
+		return err
+	}
+
+	tags, err := o.cacheTags(tx, table)
+	if err != nil {
+		return err
+	}
+
+	key = key + ":" + table + ":" + o.Hash() + ":" + o.principal()
+
+	if cached, ok := cache.Get(key); ok {
+		return json.Unmarshal(cached, dest)
+	}
+
+	if err := o.Apply(tx).Find(dest).Error; err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(dest)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	cache.Set(key, encoded, tags)
+
+	return nil
+}
+
+// cacheTags returns the tables the results of the options queried from the given table are read from:
+// the table itself, the tables of the fields, of the count filters and of the relations of the "has" and "hasnot"
+// operators, the tables of the subqueries of the "in_sub" and "notin_sub" operators, see Subquery,
+// and the tables of the included associations with the tables their filters read from.
+func (o *Options) cacheTags(tx *gorm.DB, table string) ([]string, error) {
+	tags := o.tables(table, nil)
+
+	if len(o.includes) == 0 {
+		return tags, nil
+	}
+
+	s, err := modelSchema(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve includes: %w", err)
+	}
+
+	for _, include := range o.includes {
+		related := s
+
+		for _, name := range strings.Split(include.association, ".") {
+			relationship, ok := related.Relationships.Relations[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown association %s", include.association)
+			}
+
+			related = relationship.FieldSchema
+			tags = appendTag(tags, related.Table)
+
+			if relationship.JoinTable != nil {
+				tags = appendTag(tags, relationship.JoinTable.Table)
+			}
+		}
+
+		tags = include.options.tables(related.Table, tags)
+	}
+
+	return tags, nil
+}
+
+// tables appends the given table and the tables the conditions of the options read from to the given tags,
+// without duplicates.
+func (o *Options) tables(table string, tags []string) []string {
+	tags = appendTag(tags, table)
+
+	for _, field := range o.fields {
+		if field.Table != "" {
+			tags = appendTag(tags, field.Table)
+		}
+
+		if relation, ok := o.countFilter(field); ok {
+			tags = appendTag(tags, relation.Table)
+		}
+
+		switch field.Operator {
+		case sqlOperatorExists, sqlOperatorNotExists:
+			if relation, ok := o.relation(field.Value); ok {
+				tags = appendTag(tags, relation.Table)
+			}
+		case sqlOperatorInSubquery, sqlOperatorNotInSubquery:
+			if subquery, ok := o.subquery(field.Value); ok {
+				for _, table := range subquery.Tables {
+					tags = appendTag(tags, table)
+				}
+			}
+		}
+	}
+
+	return tags
+}
+
+// appendTag appends the given tag to the tags, unless it is empty or already there.
+func appendTag(tags []string, tag string) []string {
+	if tag == "" || contains(tags, tag) {
+		return tags
+	}
+
+	return append(tags, tag)
+}
+
+// InvalidationHook is notified whenever the cached results of a table are invalidated,
+// e.g. to propagate the invalidation to other instances.
+// rows are the changed rows passed to Invalidate, they may be nil.
+type InvalidationHook func(table string, rows interface{})
+
+// Invalidator drops the cached results depending on a model when its rows change.
+type Invalidator struct {
+	cache Cache
+	namer schema.Namer
+	mu    sync.RWMutex
+	hooks []InvalidationHook
+}
+
+// NewInvalidator creates a new Invalidator for the given cache.
+func NewInvalidator(cache Cache) *Invalidator {
+	return &Invalidator{
+		cache: cache,
+		namer: schema.NamingStrategy{},
+	}
+}
+
+// OnInvalidate registers a hook notified after every invalidation.
+func (i *Invalidator) OnInvalidate(hook InvalidationHook) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.hooks = append(i.hooks, hook)
+}
+
+// Invalidate drops every cached result depending on the table of the given model,
+// which is either a model value or a table name, and notifies the registered hooks with the changed rows.
+func (i *Invalidator) Invalidate(model interface{}, rows interface{}) error {
+	table, ok := model.(string)
+	if !ok {
+		s, err := schema.Parse(model, &cacheSchemas, i.namer)
+		if err != nil {
+			return fmt.Errorf("failed to parse model: %w", err)
+		}
+
+		table = s.Table
+	}
+
+	i.cache.InvalidateTag(table)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, hook := range i.hooks {
+		hook(table, rows)
+	}
+
+	return nil
+}
+
+// Register registers GORM callbacks invalidating the cached results of a table
+// after every successful create, update or delete on it.
+func (i *Invalidator) Register(db *gorm.DB) error {
+	i.namer = db.NamingStrategy
+
+	invalidate := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.Table == "" {
+			return
+		}
+
+		i.Invalidate(tx.Statement.Table, tx.Statement.Dest)
+	}
+
+	if err := db.Callback().Create().After("gorm:create").Register("qparser:invalidate_create", invalidate); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().After("gorm:update").Register("qparser:invalidate_update", invalidate); err != nil {
+		return err
+	}
+
+	return db.Callback().Delete().After("gorm:delete").Register("qparser:invalidate_delete", invalidate)
+}
+
+// tableName returns the table queried by the given transaction,
+// parsing its model if no table is set explicitly.
+func tableName(tx *gorm.DB) (string, error) {
+	if tx.Statement.Table != "" {
+		return tx.Statement.Table, nil
+	}
+
+	if tx.Statement.Model == nil {
+		return "", fmt.Errorf("transaction has neither a model nor a table")
+	}
+
+	s, err := modelSchema(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return s.Table, nil
+}
+
+// modelSchema returns the parsed schema of the model of the given transaction.
+// The model is parsed on a copy of the statement, since it may be shared with running queries.
+func modelSchema(tx *gorm.DB) (*schema.Schema, error) {
+	stmt := tx.Session(&gorm.Session{Context: tx.Statement.Context}).Statement
+
+	if stmt.Model == nil {
+		return nil, fmt.Errorf("transaction has no model")
+	}
+
+	if err := stmt.Parse(stmt.Model); err != nil {
+		return nil, err
+	}
+
+	return stmt.Schema, nil
+}
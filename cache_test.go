@@ -0,0 +1,48 @@
+package qparser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheSweepsExpiredEntries(t *testing.T) {
+	c := NewMemoryCache(10 * time.Millisecond)
+
+	c.Set("a", []byte("1"), []string{"users", "orders"})
+	c.Set("b", []byte("2"), []string{"users"})
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.Set("c", []byte("3"), []string{"orders"})
+
+	if len(c.entries) != 1 {
+		t.Fatalf("expired entries were not swept: %v", c.entries)
+	}
+
+	if want := map[string]map[string]struct{}{"orders": {"c": {}}}; !reflect.DeepEqual(c.tags, want) {
+		t.Fatalf("got tags %v, want %v", c.tags, want)
+	}
+}
+
+func TestMemoryCacheUnlinksRemovedEntries(t *testing.T) {
+	c := NewMemoryCache(time.Minute)
+
+	c.Set("a", []byte("1"), []string{"users", "orders"})
+	c.Set("b", []byte("2"), []string{"orders"})
+	c.Set("b", []byte("3"), []string{"items"})
+
+	c.InvalidateTag("users")
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("invalidated entry is still cached")
+	}
+
+	if value, ok := c.Get("b"); !ok || string(value) != "3" {
+		t.Fatalf("got %q %t, want the replaced value", value, ok)
+	}
+
+	if want := map[string]map[string]struct{}{"items": {"b": {}}}; !reflect.DeepEqual(c.tags, want) {
+		t.Fatalf("got tags %v, want %v", c.tags, want)
+	}
+}
@@ -0,0 +1,211 @@
+package qparser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ChangeTracking names the columns incremental sync iterates over, see WithChangeTracking.
+// UpdatedColumn defaults to "updated_at" and IDColumn to "id".
+type ChangeTracking struct {
+	UpdatedColumn string
+	IDColumn      string
+}
+
+// changeCursor is the position of an incremental sync, parsed from the "modified_since" parameter.
+// A sync starts at a timestamp, including the rows modified at that time,
+// and continues after the modification time and ID of the last row returned, see Options.NextCursor.
+type changeCursor struct {
+	raw     string
+	updated time.Time
+	id      interface{}
+}
+
+// encodedCursor is the serialized form of a changeCursor continuing after a row.
+type encodedCursor struct {
+	Updated time.Time       `json:"u"`
+	ID      json.RawMessage `json:"i"`
+}
+
+// WithChangeTracking configures the columns incremental sync iterates over, "updated_at" and "id" by default.
+func WithChangeTracking(tracking ChangeTracking) ParserOption {
+	return func(c *config) {
+		c.changeTracking = tracking
+	}
+}
+
+// changeColumns returns the modification time and ID columns of incremental sync.
+func (o *Options) changeColumns() (string, string) {
+	updated, id := "updated_at", "id"
+
+	if o.config != nil {
+		if o.config.changeTracking.UpdatedColumn != "" {
+			updated = o.config.changeTracking.UpdatedColumn
+		}
+
+		if o.config.changeTracking.IDColumn != "" {
+			id = o.config.changeTracking.IDColumn
+		}
+	}
+
+	return updated, id
+}
+
+// setModifiedSince sets the position of the incremental sync of the options from the "modified_since" parameter,
+// either a RFC 3339 timestamp or date, or a cursor returned by NextCursor.
+func (o *Options) setModifiedSince(value string) error {
+	cursor, err := parseChangeCursor(value)
+	if err != nil {
+		return err
+	}
+
+	o.since = cursor
+
+	return nil
+}
+
+// parseChangeCursor parses the value of the "modified_since" parameter.
+func parseChangeCursor(value string) (*changeCursor, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if updated, err := time.Parse(layout, value); err == nil {
+			return &changeCursor{raw: value, updated: updated}, nil
+		}
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("bad modified_since, use a RFC 3339 timestamp or a cursor")
+	}
+
+	var encoded encodedCursor
+
+	if err := json.Unmarshal(data, &encoded); err != nil || len(encoded.ID) == 0 {
+		return nil, fmt.Errorf("bad modified_since, use a RFC 3339 timestamp or a cursor")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded.ID))
+	decoder.UseNumber()
+
+	var id interface{}
+
+	if err := decoder.Decode(&id); err != nil {
+		return nil, fmt.Errorf("bad modified_since, use a RFC 3339 timestamp or a cursor")
+	}
+
+	switch v := id.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			id = n
+		} else {
+			id = v.String()
+		}
+	case string:
+	default:
+		return nil, fmt.Errorf("bad modified_since, use a RFC 3339 timestamp or a cursor")
+	}
+
+	return &changeCursor{raw: value, updated: encoded.Updated, id: id}, nil
+}
+
+// sinceCondition returns the condition selecting the rows modified after the position of the incremental sync.
+// Rows modified at the same time as the last row are continued by ID, so none is skipped or returned twice.
+func (o *Options) sinceCondition() condition {
+	updated, id := o.changeColumns()
+
+	if o.since.id == nil {
+		return condition{
+			query: fmt.Sprintf("%s >= ?", updated),
+			args:  []interface{}{o.since.updated},
+		}
+	}
+
+	return condition{
+		query: fmt.Sprintf("(%s > ? OR (%s = ? AND %s > ?))", updated, updated, id),
+		args:  []interface{}{o.since.updated, o.since.updated, o.since.id},
+	}
+}
+
+// checkModifiedSince checks that incremental sync, which orders the rows itself, is not combined with sort columns.
+func (o *Options) checkModifiedSince() error {
+	if o.since != nil && len(o.sorts) > 0 {
+		return fmt.Errorf("modified_since cannot be combined with sort")
+	}
+
+	return nil
+}
+
+// orderBy returns the sort columns the rows are ordered by,
+// the modification time and ID columns during incremental sync.
+func (o *Options) orderBy() []Sort {
+	if o.since == nil {
+		return o.sorts
+	}
+
+	updated, id := o.changeColumns()
+
+	return []Sort{{Column: updated}, {Column: id}}
+}
+
+// NextCursor returns the cursor continuing the incremental sync of the options after the given last row of a page,
+// to be passed as the "modified_since" parameter of the next request.
+// The row is a map keyed by column, or a struct or a pointer to a struct, see Match,
+// and must hold the modification time and ID columns, see WithChangeTracking.
+// Outside of incremental sync, it returns the keyset cursor continuing after the row in the order of the sort columns,
+// to be passed as the "after" parameter of the next request, and the row must hold every sort column.
+func (o *Options) NextCursor(last interface{}) (string, error) {
+	if o.since == nil {
+		return o.keysetCursor(last)
+	}
+
+	lookup, _, err := rowLookup(last)
+	if err != nil {
+		return "", err
+	}
+
+	updatedColumn, idColumn := o.changeColumns()
+
+	value, ok := lookup(updatedColumn)
+	if !ok {
+		return "", fmt.Errorf("row has no %s column", updatedColumn)
+	}
+
+	value, err = scalarValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	updated, ok := value.(time.Time)
+	if !ok {
+		return "", fmt.Errorf("column %s must be a time", updatedColumn)
+	}
+
+	id, ok := lookup(idColumn)
+	if !ok {
+		return "", fmt.Errorf("row has no %s column", idColumn)
+	}
+
+	if id, err = scalarValue(id); err != nil {
+		return "", err
+	}
+
+	switch id.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, string:
+	default:
+		return "", fmt.Errorf("column %s must be an integer or a string", idColumn)
+	}
+
+	encodedID, err := json.Marshal(id)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(encodedCursor{Updated: updated, ID: encodedID})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,107 @@
+package qparser
+
+import (
+	"context"
+	"fmt"
+)
+
+// Claims are the authentication claims of the caller, e.g. its user ID, organization ID and role.
+type Claims map[string]string
+
+// ClaimsExtractor extracts the claims of the caller from the parse context,
+// e.g. from the JWT stored in it by an authentication middleware.
+type ClaimsExtractor interface {
+	Claims(ctx context.Context) (Claims, error)
+}
+
+// ClaimsExtractorFunc is an adapter allowing the use of an ordinary function as a ClaimsExtractor.
+type ClaimsExtractorFunc func(ctx context.Context) (Claims, error)
+
+// Claims calls f(ctx).
+func (f ClaimsExtractorFunc) Claims(ctx context.Context) (Claims, error) {
+	return f(ctx)
+}
+
+// ClaimFilter is a condition materialized from a claim of the caller at parse time,
+// e.g. constraining "owner_id" to the "sub" claim.
+// Operator is the operator in its query form and defaults to "eq", only comparison operators are supported.
+// Table declares the owning table of the field like the "table" tag option does.
+// If Skip is set and returns true for the claims of the caller, the condition is not added,
+// e.g. for administrators allowed to see every row.
+type ClaimFilter struct {
+	Field    string
+	Operator string
+	Claim    string
+	Table    string
+	Skip     func(claims Claims) bool
+}
+
+// WithClaimsExtractor configures the extractor of the claims used by the claim filters of the parser.
+func WithClaimsExtractor(extractor ClaimsExtractor) ParserOption {
+	return func(c *config) {
+		c.claims = extractor
+	}
+}
+
+// WithClaimFilter registers a condition materialized from the claims of the caller by every parse.
+// The parser must have a claims extractor, see WithClaimsExtractor.
+func WithClaimFilter(filter ClaimFilter) ParserOption {
+	return func(c *config) {
+		c.claimFilters = append(c.claimFilters, filter)
+	}
+}
+
+// applyClaims adds the conditions of the claim filters of the parser for the claims extracted from the context.
+// The conditions are mandatory, so they bypass the policy and the duplicate policy of the parser,
+// and a missing claim results in an error instead of an unconstrained query.
+func (o *Options) applyClaims(ctx context.Context) error {
+	if o.config == nil || len(o.config.claimFilters) == 0 {
+		return nil
+	}
+
+	if o.config.claims == nil {
+		return fmt.Errorf("parser has claim filters but no claims extractor")
+	}
+
+	claims, err := o.config.claims.Claims(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to extract claims: %w", err)
+	}
+
+	for _, filter := range o.config.claimFilters {
+		if filter.Skip != nil && filter.Skip(claims) {
+			continue
+		}
+
+		value, ok := claims[filter.Claim]
+		if !ok {
+			return fmt.Errorf("missing claim %s", filter.Claim)
+		}
+
+		op := filter.Operator
+		if op == "" {
+			op = operatorEqual
+		}
+
+		operator, err := convertOperator(op)
+		if err != nil {
+			return err
+		}
+
+		switch operator {
+		case sqlOperatorEqual, sqlOperatorNotEqual, sqlOperatorGreaterThan, sqlOperatorGreaterThanEqual, sqlOperatorLowerThan, sqlOperatorLowerThanEqual:
+		default:
+			return fmt.Errorf("operator %s is not supported by claim filters", op)
+		}
+
+		o.fields = append(o.fields, newField(Field{
+			Name:      filter.Field,
+			Value:     value,
+			Operator:  operator,
+			Table:     filter.Table,
+			mandatory: true,
+		}))
+	}
+
+	return nil
+}
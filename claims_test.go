@@ -0,0 +1,161 @@
+package qparser
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// claimsParser returns a parser constraining the orders to the organization of the caller,
+// except for the callers with the admin role claim.
+func claimsParser(opts ...ParserOption) *Parser {
+	return NewParser(append([]ParserOption{
+		WithSchema(&Schema{Fields: []SchemaField{{Name: "org_id"}, {Name: "status"}}}),
+		WithClaimsExtractor(ClaimsExtractorFunc(func(ctx context.Context) (Claims, error) {
+			claims, _ := ctx.Value(claimsContextKey{}).(Claims)
+			return claims, nil
+		})),
+		WithClaimFilter(ClaimFilter{
+			Field: "org_id",
+			Claim: "org",
+			Skip:  func(claims Claims) bool { return claims["role"] == "admin" },
+		}),
+	}, opts...)...)
+}
+
+type claimsContextKey struct{}
+
+func TestClaimFilters(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []ParserOption
+		middleware Middleware
+		claims     Claims
+		query      string
+		want       string
+		args       []interface{}
+		err        string
+	}{
+		{
+			name:   "claim added",
+			claims: Claims{"org": "7"},
+			query:  "status=eq:open",
+			want:   "WHERE status = ? AND org_id = ?",
+			args:   []interface{}{"open", "7"},
+		},
+		{
+			name:   "client cannot widen claim",
+			claims: Claims{"org": "7"},
+			query:  "org_id=eq:8",
+			want:   "WHERE org_id = ? AND org_id = ?",
+			args:   []interface{}{"8", "7"},
+		},
+		{
+			name:    "client cannot replace claim",
+			options: []ParserOption{WithDuplicatePolicy(DuplicateKeepLast)},
+			claims:  Claims{"org": "7"},
+			query:   "org_id=eq:8",
+			want:    "WHERE org_id = ? AND org_id = ?",
+			args:    []interface{}{"8", "7"},
+		},
+		{
+			name:       "middleware cannot replace claim",
+			options:    []ParserOption{WithDuplicatePolicy(DuplicateKeepLast)},
+			middleware: func(o *Options) error { return o.AddField("org_id", "8", "=") },
+			claims:     Claims{"org": "7"},
+			query:      "status=eq:open",
+			want:       "WHERE status = ? AND org_id = ? AND org_id = ?",
+			args:       []interface{}{"open", "7", "8"},
+		},
+		{
+			name:       "middleware cannot drop claim",
+			options:    []ParserOption{WithDuplicatePolicy(DuplicateKeepFirst)},
+			middleware: func(o *Options) error { return o.AddField("org_id", "8", "=") },
+			claims:     Claims{"org": "7"},
+			query:      "status=eq:open",
+			want:       "WHERE status = ? AND org_id = ? AND org_id = ?",
+			args:       []interface{}{"open", "7", "8"},
+		},
+		{
+			name:   "skipped claim",
+			claims: Claims{"role": "admin"},
+			query:  "status=eq:open",
+			want:   "WHERE status = ?",
+			args:   []interface{}{"open"},
+		},
+		{
+			name:   "missing claim",
+			claims: Claims{},
+			query:  "status=eq:open",
+			err:    "missing claim org",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := claimsParser(tt.options...)
+			if tt.middleware != nil {
+				p.Use(tt.middleware)
+			}
+
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts, err := p.ParseValuesContext(context.WithValue(context.Background(), claimsContextKey{}, tt.claims), values)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v, want %s", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query, args, err := opts.ToSQL(DialectPostgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if query != tt.want || !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("got %q %v, want %q %v", query, args, tt.want, tt.args)
+			}
+		})
+	}
+}
+
+func TestClaimFiltersSurviveSerialization(t *testing.T) {
+	p := claimsParser()
+	ctx := context.WithValue(context.Background(), claimsContextKey{}, Claims{"org": "7"})
+
+	opts, err := p.ParseValuesContext(ctx, url.Values{"status": {"eq:open"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := opts.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := context.WithValue(context.Background(), claimsContextKey{}, Claims{"org": "9"})
+
+	restored, err := p.UnmarshalOptionsContext(other, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query, args, err := restored.ToSQL(DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, wantArgs := "WHERE status = ? AND org_id = ?", []interface{}{"open", "9"}; query != want || !reflect.DeepEqual(args, wantArgs) {
+		t.Fatalf("got %q %v, want %q %v", query, args, want, wantArgs)
+	}
+}
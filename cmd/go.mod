@@ -0,0 +1,22 @@
+module github.com/0x16F/qparser/cmd
+
+go 1.21.4
+
+require (
+	github.com/0x16F/qparser v0.0.0
+	golang.org/x/perf v0.0.0-20240305160248-5eefbfdba9dd
+)
+
+require (
+	github.com/aclements/go-moremath v0.0.0-20210112150236-f10218a38794 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gorm.io/gorm v1.30.0 // indirect
+	gorm.io/plugin/dbresolver v1.6.2 // indirect
+)
+
+replace github.com/0x16F/qparser => ../
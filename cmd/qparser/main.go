@@ -0,0 +1,280 @@
+// Command qparser translates query strings to SQL, validates them against schema files
+// and gates benchmark regressions against a baseline.
+//
+// Usage:
+//
+//	qparser translate [--dialect=postgres] [--placeholders=?] [--schema=file] [--table=name] 'name=like:bob&age=gte:18&sort=name'
+//	qparser validate --schema=file 'name=like:bob&age=gte:18'
+//	qparser bench [--threshold=5] [--alpha=0.05] old.txt new.txt
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/0x16F/qparser"
+	"golang.org/x/perf/benchfmt"
+	"golang.org/x/perf/benchmath"
+)
+
+const usage = `usage:
+  qparser translate [--dialect=postgres] [--placeholders=?] [--schema=file] [--table=name] 'query'
+  qparser validate --schema=file 'query'
+  qparser bench [--threshold=5] [--alpha=0.05] old.txt new.txt
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "translate":
+		err = translate(os.Args[2:])
+	case "validate":
+		err = validate(os.Args[2:])
+	case "bench":
+		err = bench(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// translate prints the SQL and the arguments generated for the given query string.
+// Without a schema, every parameter of the query string is accepted as a field.
+func translate(args []string) error {
+	flags := flag.NewFlagSet("translate", flag.ExitOnError)
+	dialect := flags.String("dialect", string(qparser.DialectPostgres), "SQL dialect: postgres, mysql or sqlite")
+	placeholders := flags.String("placeholders", string(qparser.PlaceholderQuestion), "placeholder style: ?, $ or :")
+	schemaPath := flags.String("schema", "", "schema file the query is parsed with")
+	table := flags.String("table", "", "table selected from, only the clauses are printed when empty")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("translate expects exactly one query string")
+	}
+
+	values, err := url.ParseQuery(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("bad query string: %w", err)
+	}
+
+	schema := permissiveSchema(values)
+	if *schemaPath != "" {
+		if schema, err = qparser.LoadSchemaFile(*schemaPath); err != nil {
+			return err
+		}
+	}
+
+	options, err := qparser.NewParser(qparser.WithSchema(schema)).ParseValues(values)
+	if err != nil {
+		return err
+	}
+
+	query, queryArgs, err := options.ToSQLWithPlaceholders(qparser.Dialect(*dialect), qparser.PlaceholderStyle(*placeholders))
+	if err != nil {
+		return err
+	}
+
+	interpolated, err := options.InterpolatedSQL(qparser.Dialect(*dialect))
+	if err != nil {
+		return err
+	}
+
+	if *table != "" {
+		query = strings.TrimSpace(fmt.Sprintf("SELECT * FROM %s %s", *table, query))
+		interpolated = strings.TrimSpace(fmt.Sprintf("SELECT * FROM %s %s", *table, interpolated))
+	}
+
+	encodedArgs, err := json.Marshal(queryArgs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("SQL:          %s\n", query)
+	fmt.Printf("Args:         %s\n", encodedArgs)
+	fmt.Printf("Interpolated: %s\n", interpolated)
+
+	return nil
+}
+
+// validate parses the given query string with the given schema and reports the first error.
+func validate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	schemaPath := flags.String("schema", "", "schema file the query is validated against")
+	flags.Parse(args)
+
+	if *schemaPath == "" {
+		return fmt.Errorf("validate requires --schema")
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("validate expects exactly one query string")
+	}
+
+	values, err := url.ParseQuery(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("bad query string: %w", err)
+	}
+
+	schema, err := qparser.LoadSchemaFile(*schemaPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := qparser.NewParser(qparser.WithSchema(schema)).ParseValues(values); err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+
+	return nil
+}
+
+// bench compares the benchmark results of the given files, recorded with go test -bench,
+// and fails if any benchmark of the new results is slower or allocates more than in the baseline,
+// by more than the threshold and with a statistically significant difference, as benchstat reports it.
+func bench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	threshold := flags.Float64("threshold", 5, "tolerated regression in percent")
+	alpha := flags.Float64("alpha", benchmath.DefaultThresholds.CompareAlpha, "significance level of the comparison")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("bench needs a baseline and a new results file")
+	}
+
+	old, err := readBenchmarks(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	results, err := readBenchmarks(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	thresholds := &benchmath.Thresholds{CompareAlpha: *alpha}
+	regressed := make([]string, 0)
+
+	for _, key := range sortedKeys(results) {
+		baseline, ok := old[key]
+		if !ok {
+			continue
+		}
+
+		before := benchmath.NewSample(baseline, thresholds)
+		after := benchmath.NewSample(results[key], thresholds)
+
+		comparison := benchmath.AssumeNothing.Compare(before, after)
+		center := func(s *benchmath.Sample) float64 { return benchmath.AssumeNothing.Summary(s, 0.95).Center }
+
+		delta := comparison.FormatDelta(center(before), center(after))
+		fmt.Printf("%s\t%s\t(%s)\n", key, delta, comparison)
+
+		if comparison.P < comparison.Alpha && center(after) > center(before)*(1+*threshold/100) {
+			regressed = append(regressed, key)
+		}
+	}
+
+	if len(regressed) > 0 {
+		return fmt.Errorf("benchmarks regressed: %s", strings.Join(regressed, ", "))
+	}
+
+	return nil
+}
+
+// readBenchmarks reads the time and allocations per operation of every benchmark run in the given file,
+// keyed by benchmark name and unit, e.g. "ParseValues/small sec/op".
+func readBenchmarks(path string) (map[string][]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	samples := make(map[string][]float64)
+
+	reader := benchfmt.NewReader(file, path)
+	for reader.Scan() {
+		switch record := reader.Result().(type) {
+		case *benchfmt.SyntaxError:
+			return nil, record
+		case *benchfmt.Result:
+			for _, value := range record.Values {
+				if value.Unit == "sec/op" || value.Unit == "allocs/op" {
+					key := string(record.Name.Full()) + " " + value.Unit
+					samples[key] = append(samples[key], value.Value)
+				}
+			}
+		}
+	}
+
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("%s holds no benchmark results", path)
+	}
+
+	return samples, nil
+}
+
+// sortedKeys returns the keys of the given samples in alphabetical order.
+func sortedKeys(samples map[string][]float64) []string {
+	keys := make([]string, 0, len(samples))
+	for key := range samples {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// permissiveSchema returns a schema accepting every parameter of the given values as a field,
+// and every column they sort by or select.
+// The fields are declared in alphabetical order, so the generated SQL is stable.
+func permissiveSchema(values url.Values) *qparser.Schema {
+	schema := &qparser.Schema{}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch name {
+		case "limit", "offset":
+		case "sort":
+			for _, column := range strings.Split(values.Get(name), ",") {
+				schema.Sortable = append(schema.Sortable, strings.TrimPrefix(strings.TrimSpace(column), "-"))
+			}
+		case "fields":
+			for _, column := range strings.Split(values.Get(name), ",") {
+				schema.Selectable = append(schema.Selectable, strings.TrimSpace(column))
+			}
+		default:
+			schema.Fields = append(schema.Fields, qparser.SchemaField{Name: name})
+		}
+	}
+
+	return schema
+}
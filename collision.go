@@ -0,0 +1,172 @@
+package qparser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrConditionCollision is returned by RejectCollisions for filters on columns the query is already constrained on.
+var ErrConditionCollision = errors.New("filter collides with a condition of the query")
+
+// Collision describes a filter of the options on a column the transaction they are applied to is already constrained on,
+// e.g. a handler scoping the query to a tenant_id while the client also filters tenant_id.
+// Column is the colliding column, Existing the condition already present on the transaction,
+// and Field the colliding filter.
+type Collision struct {
+	Column   string
+	Existing string
+	Field    Field
+}
+
+// CollisionHandler is notified of every Collision, see WithCollisionHandler.
+// Returning an error fails the query with it, returning nil only reports the collision, e.g. to log a warning.
+type CollisionHandler func(collision Collision) error
+
+// WithCollisionHandler configures the parser to inspect the conditions already present on the transactions
+// the options are applied to, and to notify the given handler of every filter on a column they already constrain,
+// preventing subtle double filtering bugs. A handler returning an error fails the query with it, see RejectCollisions.
+// Only the WHERE conditions added before the options are applied are inspected, not those of scopes registered
+// with Scopes, which GORM only runs when the query is executed.
+func WithCollisionHandler(handler CollisionHandler) ParserOption {
+	return func(c *config) {
+		c.collisions = handler
+	}
+}
+
+// RejectCollisions is a CollisionHandler failing the query with ErrConditionCollision on every collision.
+func RejectCollisions(collision Collision) error {
+	return fmt.Errorf("%w: %s is already constrained by %s", ErrConditionCollision, collision.Column, collision.Existing)
+}
+
+// conditionColumnRegexp matches the columns compared by a raw SQL condition, e.g. "tenant_id" in "tenant_id = ?".
+var conditionColumnRegexp = regexp.MustCompile(`(?i)([a-z_][a-z0-9_.]*)\s*(?:=|<>|!=|<=|>=|<|>|\s(?:not\s+)?(?:in|like|ilike|between)\b|\sis\b)`)
+
+// existingCondition is a condition already present on a transaction and a column it constrains.
+type existingCondition struct {
+	column string
+	sql    string
+}
+
+// checkCollisions notifies the collision handler of the parser of the filters of the options
+// on columns the given transaction is already constrained on, returning the first error of the handler.
+func (o *Options) checkCollisions(tx *gorm.DB) error {
+	if o.config == nil || o.config.collisions == nil || tx.Statement == nil {
+		return nil
+	}
+
+	where, ok := tx.Statement.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok || len(where.Exprs) == 0 {
+		return nil
+	}
+
+	existing := existingConditions(where.Exprs)
+
+	for _, field := range o.fields {
+		if _, ok := o.countFilter(field); ok {
+			continue
+		}
+
+		if field.Operator == sqlOperatorExists || field.Operator == sqlOperatorNotExists {
+			continue
+		}
+
+		column := o.column(field)
+
+		for _, condition := range existing {
+			if !sameColumn(condition.column, column) {
+				continue
+			}
+
+			if err := o.config.collisions(Collision{Column: column, Existing: condition.sql, Field: *field}); err != nil {
+				return err
+			}
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// existingConditions returns the columns constrained by the given WHERE expressions.
+func existingConditions(exprs []clause.Expression) []existingCondition {
+	conditions := make([]existingCondition, 0, len(exprs))
+
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case clause.Expr:
+			conditions = append(conditions, rawConditions(e.SQL)...)
+		case clause.NamedExpr:
+			conditions = append(conditions, rawConditions(e.SQL)...)
+		case clause.Eq:
+			conditions = append(conditions, comparison(e.Column, "="))
+		case clause.Neq:
+			conditions = append(conditions, comparison(e.Column, "<>"))
+		case clause.Gt:
+			conditions = append(conditions, comparison(e.Column, ">"))
+		case clause.Gte:
+			conditions = append(conditions, comparison(e.Column, ">="))
+		case clause.Lt:
+			conditions = append(conditions, comparison(e.Column, "<"))
+		case clause.Lte:
+			conditions = append(conditions, comparison(e.Column, "<="))
+		case clause.IN:
+			conditions = append(conditions, comparison(e.Column, "IN"))
+		case clause.Like:
+			conditions = append(conditions, comparison(e.Column, "LIKE"))
+		case clause.AndConditions:
+			conditions = append(conditions, existingConditions(e.Exprs)...)
+		case clause.OrConditions:
+			conditions = append(conditions, existingConditions(e.Exprs)...)
+		case clause.NotConditions:
+			conditions = append(conditions, existingConditions(e.Exprs)...)
+		}
+	}
+
+	return conditions
+}
+
+// rawConditions returns the columns compared by the given raw SQL condition.
+func rawConditions(sql string) []existingCondition {
+	unquoted := strings.NewReplacer(`"`, "", "`", "").Replace(sql)
+
+	matches := conditionColumnRegexp.FindAllStringSubmatch(unquoted, -1)
+	conditions := make([]existingCondition, 0, len(matches))
+
+	for _, match := range matches {
+		conditions = append(conditions, existingCondition{column: match[1], sql: sql})
+	}
+
+	return conditions
+}
+
+// comparison returns the condition comparing the given column of a GORM clause with the given operator.
+func comparison(column interface{}, operator string) existingCondition {
+	name := fmt.Sprint(column)
+
+	if c, ok := column.(clause.Column); ok {
+		name = c.Name
+		if c.Table != "" && c.Table != clause.CurrentTable {
+			name = c.Table + "." + c.Name
+		}
+	}
+
+	return existingCondition{column: name, sql: name + " " + operator + " ?"}
+}
+
+// sameColumn reports whether the given columns are the same, comparing only their names
+// unless both are qualified by a table.
+func sameColumn(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+
+	if strings.Contains(a, ".") && strings.Contains(b, ".") {
+		return a == b
+	}
+
+	return a[strings.LastIndex(a, ".")+1:] == b[strings.LastIndex(b, ".")+1:]
+}
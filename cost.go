@@ -0,0 +1,80 @@
+package qparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueryTooComplex is returned when the cost of the parsed options exceeds the budget
+// left by the deadline of the context, see WithCostBudget.
+var ErrQueryTooComplex = errors.New("query is too complex for the remaining time, simplify your query")
+
+// WithOperatorCost registers the cost weight of the given operator in its query form, e.g. "like".
+// Operators without a registered weight cost 1.
+func WithOperatorCost(operator string, cost int) ParserOption {
+	return func(c *config) {
+		c.costs[operator] = cost
+	}
+}
+
+// WithCostBudget configures the parser to reject expensive options when the deadline of the parse context is near.
+// The options may cost at most costPerSecond for every second left until the deadline,
+// otherwise parsing fails with ErrQueryTooComplex instead of letting the query time out.
+// Contexts without a deadline are not limited.
+func WithCostBudget(costPerSecond float64) ParserOption {
+	return func(c *config) {
+		c.costPerSecond = costPerSecond
+	}
+}
+
+// Cost returns the cost of the fields of the options according to the operator weights of the parser.
+// Every range of the "range" operator and every element of the "anyof" operator costs the weight of the operator,
+// since they are combined with OR, as does every word of the "words" operator, each one being a separate pattern match.
+func (o *Options) Cost() int {
+	total := 0
+
+	for _, field := range o.fields {
+		operator := revertOperator(field.Operator)
+
+		cost := 1
+		if o.config != nil {
+			if weight, ok := o.config.costs[operator]; ok {
+				cost = weight
+			}
+		}
+
+		switch field.Operator {
+		case sqlOperatorRange:
+			cost *= len(field.Values) / 2
+		case sqlOperatorAnyOf, sqlOperatorWords:
+			cost *= len(field.Values)
+		}
+
+		total += cost
+	}
+
+	return total
+}
+
+// checkBudget returns ErrQueryTooComplex if the cost of the options exceeds the budget
+// left by the deadline of the given context.
+func (o *Options) checkBudget(ctx context.Context) error {
+	if o.config == nil || o.config.costPerSecond <= 0 {
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	budget := int(time.Until(deadline).Seconds() * o.config.costPerSecond)
+
+	if cost := o.Cost(); cost > budget {
+		return fmt.Errorf("%w: cost %d exceeds budget %d", ErrQueryTooComplex, cost, budget)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package qparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Count returns the exact number of rows matching the filters of the options,
+// ignoring sorting and pagination, including the cursors of keyset pagination and incremental sync.
+// The transaction must have a model or table set.
+func (o *Options) Count(tx *gorm.DB) (int64, error) {
+	var total int64
+
+	if err := o.filter(tx).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// FindWithCount executes the page query into dest while counting the matching rows concurrently.
+// If the exact count does not finish within the given timeout, it is canceled and
+// an estimated count is returned instead, with exact set to false.
+// The estimate is computed by EstimateCount.
+// The transaction must have a model or table set.
+func (o *Options) FindWithCount(tx *gorm.DB, dest interface{}, timeout time.Duration) (total int64, exact bool, err error) {
+	base := tx.Session(&gorm.Session{})
+
+	ctx, cancel := context.WithTimeout(base.Statement.Context, timeout)
+	defer cancel()
+
+	type countResult struct {
+		total int64
+		err   error
+	}
+
+	counted := make(chan countResult, 1)
+
+	go func() {
+		total, err := o.Count(base.WithContext(ctx))
+		counted <- countResult{total: total, err: err}
+	}()
+
+	if err := o.Apply(base).Find(dest).Error; err != nil {
+		return 0, false, err
+	}
+
+	select {
+	case result := <-counted:
+		if result.err == nil {
+			return result.total, true, nil
+		}
+
+		if ctx.Err() == nil {
+			return 0, false, result.err
+		}
+	case <-ctx.Done():
+	}
+
+	total, err = o.EstimateCount(base)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return total, false, nil
+}
+
+// EstimateCount returns the number of rows matching the filters of the options as estimated
+// by the query planner, for interfaces showing "about 12,400 results" without a full COUNT scan.
+// The filtered query is explained with EXPLAIN FORMAT JSON and the row estimate of its plan is returned.
+// The query is built in a dry run session and explained with its values bound as arguments, never inlined,
+// and sent to the connection as is, so the question marks of the postgres JSON operators are not parsed again.
+// It is supported for postgres and mysql only.
+// The transaction must have a model or table set.
+func (o *Options) EstimateCount(tx *gorm.DB) (int64, error) {
+	dialect := dialectOf(tx)
+
+	var explain string
+
+	switch dialect {
+	case DialectPostgres:
+		explain = "EXPLAIN (FORMAT JSON) "
+	case DialectMySQL:
+		explain = "EXPLAIN FORMAT=JSON "
+	default:
+		return 0, fmt.Errorf("estimated count is not supported for dialect %q", dialect)
+	}
+
+	stmt := o.filter(tx.Session(&gorm.Session{DryRun: true})).Find(&[]map[string]interface{}{}).Statement
+	if stmt.Error != nil {
+		return 0, fmt.Errorf("failed to build query: %w", stmt.Error)
+	}
+
+	var plan string
+
+	row := stmt.ConnPool.QueryRowContext(stmt.Context, explain+stmt.SQL.String(), stmt.Vars...)
+	if err := row.Scan(&plan); err != nil {
+		return 0, fmt.Errorf("failed to explain query: %w", err)
+	}
+
+	if dialect == DialectPostgres {
+		return parsePostgresPlan(plan)
+	}
+
+	return parseMySQLPlan(plan)
+}
+
+// parsePostgresPlan returns the row estimate of the top node of a postgres JSON plan.
+func parsePostgresPlan(plan string) (int64, error) {
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+
+	if err := json.Unmarshal([]byte(plan), &explained); err != nil {
+		return 0, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	if len(explained) == 0 {
+		return 0, fmt.Errorf("empty plan")
+	}
+
+	return int64(explained[0].Plan.Rows), nil
+}
+
+// parseMySQLPlan returns the row estimate of a mysql JSON plan.
+// For joins, the rows produced by the last table of the nested loop are the rows of the result.
+func parseMySQLPlan(plan string) (int64, error) {
+	type table struct {
+		RowsProducedPerJoin float64 `json:"rows_produced_per_join"`
+	}
+
+	var explained struct {
+		QueryBlock struct {
+			Table      *table `json:"table"`
+			NestedLoop []struct {
+				Table table `json:"table"`
+			} `json:"nested_loop"`
+		} `json:"query_block"`
+	}
+
+	if err := json.Unmarshal([]byte(plan), &explained); err != nil {
+		return 0, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	block := explained.QueryBlock
+
+	if block.Table != nil {
+		return int64(block.Table.RowsProducedPerJoin), nil
+	}
+
+	if len(block.NestedLoop) > 0 {
+		return int64(block.NestedLoop[len(block.NestedLoop)-1].Table.RowsProducedPerJoin), nil
+	}
+
+	return 0, fmt.Errorf("plan has no row estimate")
+}
@@ -0,0 +1,253 @@
+package qparser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// keysetCursor is the position of keyset pagination, parsed from the "after" parameter.
+// It holds the values of the sort columns of the last row of the previous page, see Options.NextCursor.
+type keysetCursor struct {
+	raw  string
+	keys []cursorKey
+}
+
+// cursorKey is the value of a sort column of the row a keyset cursor continues after.
+type cursorKey struct {
+	column string
+	desc   bool
+	value  interface{}
+}
+
+// encodedKey is the serialized form of a cursorKey. Times are tagged, so they are bound as times again.
+type encodedKey struct {
+	Column string          `json:"c"`
+	Desc   bool            `json:"d,omitempty"`
+	Type   string          `json:"t,omitempty"`
+	Value  json.RawMessage `json:"v"`
+}
+
+// setAfter sets the position of the keyset pagination of the options from the "after" parameter,
+// a cursor returned by NextCursor.
+func (o *Options) setAfter(value string) error {
+	cursor, err := parseKeysetCursor(value)
+	if err != nil {
+		return err
+	}
+
+	o.after = cursor
+
+	return nil
+}
+
+// parseKeysetCursor parses the value of the "after" parameter.
+func parseKeysetCursor(value string) (*keysetCursor, error) {
+	errBadCursor := fmt.Errorf("bad after, use a cursor returned for the previous page")
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errBadCursor
+	}
+
+	var encoded []encodedKey
+
+	if err := json.Unmarshal(data, &encoded); err != nil || len(encoded) == 0 {
+		return nil, errBadCursor
+	}
+
+	cursor := &keysetCursor{raw: value, keys: make([]cursorKey, 0, len(encoded))}
+
+	for _, key := range encoded {
+		if !identifierRegexp.MatchString(key.Column) {
+			return nil, errBadCursor
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(key.Value))
+		decoder.UseNumber()
+
+		var v interface{}
+
+		if err := decoder.Decode(&v); err != nil {
+			return nil, errBadCursor
+		}
+
+		switch value := v.(type) {
+		case json.Number:
+			if n, err := value.Int64(); err == nil {
+				v = n
+			} else if f, err := value.Float64(); err == nil {
+				v = f
+			} else {
+				return nil, errBadCursor
+			}
+		case string:
+			if key.Type == "time" {
+				t, err := time.Parse(time.RFC3339Nano, value)
+				if err != nil {
+					return nil, errBadCursor
+				}
+
+				v = t
+			}
+		case bool:
+		default:
+			return nil, errBadCursor
+		}
+
+		cursor.keys = append(cursor.keys, cursorKey{column: key.Column, desc: key.Desc, value: v})
+	}
+
+	return cursor, nil
+}
+
+// checkAfter checks that keyset pagination is not combined with incremental sync,
+// and that the cursor continues the sort columns of the options, in the same directions.
+func (o *Options) checkAfter() error {
+	if o.after == nil {
+		return nil
+	}
+
+	if o.since != nil {
+		return fmt.Errorf("after cannot be combined with modified_since")
+	}
+
+	sorts := o.orderBy()
+
+	if len(sorts) != len(o.after.keys) {
+		return fmt.Errorf("after does not match the sort columns")
+	}
+
+	for i, sort := range sorts {
+		if key := o.after.keys[i]; key.column != sort.Column || key.desc != sort.Desc {
+			return fmt.Errorf("after does not match the sort columns")
+		}
+	}
+
+	return nil
+}
+
+// afterCondition returns the condition selecting the rows following the position of the keyset pagination
+// in the order of the sort columns.
+// When all columns are sorted in the same direction, postgres and sqlite compare the columns as a tuple,
+// e.g. "(created_at, id) < (?, ?)", which a composite index serves directly.
+// Mixed directions, and mysql, which does not use indexes for tuple comparisons reliably,
+// use the expanded equivalent, e.g. "(created_at < ? OR (created_at = ? AND id > ?))".
+func (o *Options) afterCondition(dialect Dialect) condition {
+	keys := o.after.keys
+
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		columns = append(columns, o.viewColumn(key.column))
+	}
+
+	uniform := true
+	for _, key := range keys {
+		uniform = uniform && key.desc == keys[0].desc
+	}
+
+	if len(keys) > 1 && uniform && dialect != DialectMySQL {
+		args := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			args = append(args, key.value)
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+
+		return condition{
+			query: fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), keysetOperator(keys[0]), placeholders),
+			args:  args,
+		}
+	}
+
+	queries := make([]string, 0, len(keys))
+	args := make([]interface{}, 0, len(keys)*(len(keys)+1)/2)
+
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = ?", columns[j]))
+			args = append(args, keys[j].value)
+		}
+
+		parts = append(parts, fmt.Sprintf("%s %s ?", columns[i], keysetOperator(key)))
+		args = append(args, key.value)
+
+		query := strings.Join(parts, " AND ")
+		if len(parts) > 1 {
+			query = "(" + query + ")"
+		}
+
+		queries = append(queries, query)
+	}
+
+	query := queries[0]
+	if len(queries) > 1 {
+		query = "(" + strings.Join(queries, " OR ") + ")"
+	}
+
+	return condition{query: query, args: args}
+}
+
+// keysetOperator returns the operator selecting the values following the given key in its sort direction.
+func keysetOperator(key cursorKey) string {
+	if key.desc {
+		return sqlOperatorLowerThan
+	}
+
+	return sqlOperatorGreaterThan
+}
+
+// keysetCursor returns the cursor continuing the keyset pagination of the options after the given last row.
+func (o *Options) keysetCursor(last interface{}) (string, error) {
+	sorts := o.orderBy()
+	if len(sorts) == 0 {
+		return "", fmt.Errorf("keyset pagination requires sort columns")
+	}
+
+	lookup, _, err := rowLookup(last)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := make([]encodedKey, 0, len(sorts))
+
+	for _, sort := range sorts {
+		value, ok := lookup(sort.Column)
+		if !ok {
+			return "", fmt.Errorf("row has no %s column", sort.Column)
+		}
+
+		if value, err = scalarValue(value); err != nil {
+			return "", err
+		}
+
+		key := encodedKey{Column: sort.Column, Desc: sort.Desc}
+
+		switch v := value.(type) {
+		case time.Time:
+			key.Type = "time"
+			value = v.Format(time.RFC3339Nano)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, string, bool:
+		default:
+			return "", fmt.Errorf("column %s must be a time, a number, a string or a bool", sort.Column)
+		}
+
+		if key.Value, err = json.Marshal(value); err != nil {
+			return "", err
+		}
+
+		encoded = append(encoded, key)
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,229 @@
+package qparser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DuplicateKeepAll keeps every duplicate condition, combining them with AND.
+	DuplicateKeepAll DuplicatePolicy = iota
+	// DuplicateError rejects the options when a duplicate condition is added.
+	DuplicateError
+	// DuplicateKeepFirst keeps the first condition and drops its duplicates.
+	DuplicateKeepFirst
+	// DuplicateKeepLast replaces the condition by its last duplicate.
+	DuplicateKeepLast
+	// DuplicateMerge merges duplicate conditions into the tightest equivalent one.
+	DuplicateMerge
+)
+
+// DuplicatePolicy decides what happens when a condition is added on a column
+// already filtered with the same operator, e.g. "age=gt:18&age=gt:21".
+type DuplicatePolicy int
+
+// WithDuplicatePolicy configures how the parser handles duplicate conditions, DuplicateKeepAll by default.
+// Conditions are duplicates when they filter the same column of the same table with the same operator.
+func WithDuplicatePolicy(policy DuplicatePolicy) ParserOption {
+	return func(c *config) {
+		c.duplicates = policy
+	}
+}
+
+// appendField appends the given field to the options, applying the duplicate policy of the parser.
+// Mandatory conditions, e.g. those of claim filters, are never dropped, replaced or merged by the policy.
+func (o *Options) appendField(field *Field) error {
+	policy := DuplicateKeepAll
+	if o.config != nil {
+		policy = o.config.duplicates
+	}
+
+	if policy == DuplicateKeepAll {
+		o.fields = append(o.fields, field)
+		return nil
+	}
+
+	for i, existing := range o.fields {
+		if existing.mandatory {
+			continue
+		}
+
+		if existing.Table != field.Table || existing.Name != field.Name || existing.Operator != field.Operator {
+			continue
+		}
+
+		switch policy {
+		case DuplicateError:
+			return fmt.Errorf("duplicate filter on %s with operator %s", field.Name, revertOperator(field.Operator))
+		case DuplicateKeepFirst:
+			return nil
+		case DuplicateKeepLast:
+			o.fields[i] = field
+			return nil
+		case DuplicateMerge:
+			merged, ok, err := mergeFields(existing, field)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				o.fields[i] = merged
+				return nil
+			}
+		}
+	}
+
+	o.fields = append(o.fields, field)
+
+	return nil
+}
+
+// mergeFields merges two conditions on the same column with the same operator into the tightest one.
+// The "gt" and "gte" operators keep the greatest value, "lt" and "lte" keep the lowest one,
+// and single ranges are intersected. Conditions with equal values are merged into one.
+// Equal conditions with different values and disjoint ranges can never match and result in an error.
+// Values are only compared if both are numbers or both are times, see orderValues, since the order of strings
+// depends on the collation of the column. If the conditions cannot be merged, ok is false and both must be kept.
+func mergeFields(a, b *Field) (merged *Field, ok bool, err error) {
+	switch a.Operator {
+	case sqlOperatorGreaterThan, sqlOperatorGreaterThanEqual:
+		cmp, ok := orderValues(b.Value, a.Value)
+		if !ok {
+			return nil, false, nil
+		}
+
+		if cmp > 0 {
+			return b, true, nil
+		}
+
+		return a, true, nil
+	case sqlOperatorLowerThan, sqlOperatorLowerThanEqual:
+		cmp, ok := orderValues(b.Value, a.Value)
+		if !ok {
+			return nil, false, nil
+		}
+
+		if cmp < 0 {
+			return b, true, nil
+		}
+
+		return a, true, nil
+	case sqlOperatorEqual:
+		if a.Value == b.Value {
+			return a, true, nil
+		}
+
+		cmp, ok := orderValues(a.Value, b.Value)
+		if !ok {
+			return nil, false, nil
+		}
+
+		if cmp != 0 {
+			return nil, false, fmt.Errorf("conflicting filters on %s", a.Name)
+		}
+
+		return a, true, nil
+	case sqlOperatorRange:
+		if len(a.Values) != 2 || len(b.Values) != 2 {
+			return nil, false, nil
+		}
+
+		lower, lowerOK := orderValues(b.Values[0], a.Values[0])
+		upper, upperOK := orderValues(b.Values[1], a.Values[1])
+
+		if !lowerOK || !upperOK {
+			return nil, false, nil
+		}
+
+		low, high := a.Values[0], a.Values[1]
+		if lower > 0 {
+			low = b.Values[0]
+		}
+
+		if upper < 0 {
+			high = b.Values[1]
+		}
+
+		cmp, ok := orderValues(low, high)
+		if !ok {
+			return nil, false, nil
+		}
+
+		if cmp > 0 {
+			return nil, false, fmt.Errorf("conflicting filters on %s", a.Name)
+		}
+
+		field := *a
+		field.Value = low + " to " + high
+		field.Values = []string{low, high}
+
+		return &field, true, nil
+	}
+
+	if a.Value == b.Value && strings.Join(a.Values, ",") == strings.Join(b.Values, ",") {
+		return a, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// compareTimeLayouts are the layouts of the times compared by orderValues,
+// the first ones holding a time zone and the last ones without.
+var compareTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// compareTimeZoned is the number of layouts of compareTimeLayouts holding a time zone.
+const compareTimeZoned = 2
+
+// orderValues compares two filter values, numerically if both are numbers
+// and as instants if both are times, either both with a time zone or both without.
+// ok is false if the values cannot be ordered without knowing the type and the collation of the column,
+// e.g. strings, which databases compare case-insensitively or in locale order, or times with and without a time zone.
+func orderValues(a, b string) (cmp int, ok bool) {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+
+	if errA == nil && errB == nil {
+		if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			return 0, false
+		}
+
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	s, zonedA, okA := parseComparedTime(a)
+	t, zonedB, okB := parseComparedTime(b)
+
+	if !okA || !okB || zonedA != zonedB {
+		return 0, false
+	}
+
+	return s.Compare(t), true
+}
+
+// parseComparedTime parses the given filter value with compareTimeLayouts,
+// reporting whether it holds a time zone.
+func parseComparedTime(value string) (t time.Time, zoned bool, ok bool) {
+	for i, layout := range compareTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, i < compareTimeZoned, true
+		}
+	}
+
+	return time.Time{}, false, false
+}
@@ -0,0 +1,100 @@
+package qparser
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateMerge(t *testing.T) {
+	p := NewParser(WithDuplicatePolicy(DuplicateMerge), WithSchema(&Schema{
+		Fields: []SchemaField{{Name: "age"}, {Name: "name"}, {Name: "created_at"}},
+	}))
+
+	tests := []struct {
+		name  string
+		query string
+		sql   string
+		args  []interface{}
+		err   bool
+	}{
+		{
+			name:  "numbers",
+			query: "age=gt:18&age=gt:21",
+			sql:   "WHERE age > ?",
+			args:  []interface{}{"21"},
+		},
+		{
+			name:  "times with different offsets",
+			query: "created_at=lt:2024-01-01T10:00:00%2B02:00&created_at=lt:2024-01-01T09:00:00Z",
+			sql:   "WHERE created_at < ?",
+			args:  []interface{}{"2024-01-01T10:00:00+02:00"},
+		},
+		{
+			name:  "ranges",
+			query: "age=rng:10 to 50&age=rng:20 to 100",
+			sql:   "WHERE age BETWEEN ? AND ?",
+			args:  []interface{}{"20", "50"},
+		},
+		{
+			name:  "strings",
+			query: "name=gt:b&name=gt:C",
+			sql:   "WHERE name > ? AND name > ?",
+			args:  []interface{}{"b", "C"},
+		},
+		{
+			name:  "times with and without time zone",
+			query: "created_at=gte:2024-01-01&created_at=gte:2024-01-01T00:00:00Z",
+			sql:   "WHERE created_at >= ? AND created_at >= ?",
+			args:  []interface{}{"2024-01-01", "2024-01-01T00:00:00Z"},
+		},
+		{
+			name:  "different strings",
+			query: "name=eq:Bob&name=eq:bob",
+			sql:   "WHERE name = ? AND name = ?",
+			args:  []interface{}{"Bob", "bob"},
+		},
+		{
+			name:  "equal numbers",
+			query: "age=eq:18&age=eq:18.0",
+			sql:   "WHERE age = ?",
+			args:  []interface{}{"18"},
+		},
+		{
+			name:  "conflicting numbers",
+			query: "age=eq:18&age=eq:21",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts, err := p.ParseValues(values)
+			if tt.err {
+				if err == nil {
+					t.Fatal("conflicting filters were accepted")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query, args, err := opts.ToSQL(DialectPostgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if query != tt.sql || !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("got %q %v, want %q %v", query, args, tt.sql, tt.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package qparser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FilterEvent is a normalized description of parsed options published for product analytics.
+// It describes which fields, operators and columns are used, without the filtered values.
+// Sorts are the sort columns, prefixed with "-" when sorted in descending order.
+type FilterEvent struct {
+	Time    time.Time          `json:"time"`
+	Fields  []FilterEventField `json:"fields"`
+	Sorts   []string           `json:"sorts,omitempty"`
+	Selects []string           `json:"selects,omitempty"`
+	Limit   int                `json:"limit,omitempty"`
+	Offset  int                `json:"offset,omitempty"`
+}
+
+// FilterEventField is a field of a FilterEvent, with the operator in its query form.
+type FilterEventField struct {
+	Name     string `json:"name"`
+	Operator string `json:"operator"`
+	Table    string `json:"table,omitempty"`
+	Class    string `json:"class,omitempty"`
+}
+
+// Emitter publishes filter events, e.g. to a Kafka topic or a webhook.
+type Emitter interface {
+	Emit(ctx context.Context, event FilterEvent) error
+}
+
+// EmitterFunc is an adapter allowing the use of an ordinary function as an Emitter.
+type EmitterFunc func(ctx context.Context, event FilterEvent) error
+
+// Emit calls f(ctx, event).
+func (f EmitterFunc) Emit(ctx context.Context, event FilterEvent) error {
+	return f(ctx, event)
+}
+
+// webhookEmitter posts filter events as JSON to a URL.
+type webhookEmitter struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookEmitter creates an Emitter posting every event as a JSON document to the given URL.
+// A nil client uses http.DefaultClient.
+func NewWebhookEmitter(url string, client *http.Client) Emitter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &webhookEmitter{url: url, client: client}
+}
+
+// Emit posts the event to the URL of the emitter.
+func (e *webhookEmitter) Emit(ctx context.Context, event FilterEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// EmitterConfig configures an AsyncEmitter.
+// SampleRate is the fraction of the parsed options published, between 0 and 1, and defaults to 1 when zero.
+// BufferSize is the number of events buffered while the emitter is busy, 1024 by default,
+// events are dropped when the buffer is full so parsing never waits for the emitter.
+// OnError is notified with the errors of the emitter, if set.
+type EmitterConfig struct {
+	SampleRate float64
+	BufferSize int
+	OnError    func(error)
+}
+
+// AsyncEmitter publishes the filter events of a parser in the background, see WithEmitter.
+type AsyncEmitter struct {
+	emitter Emitter
+	config  EmitterConfig
+	events  chan FilterEvent
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+}
+
+// NewAsyncEmitter creates a new AsyncEmitter publishing events with the given emitter.
+// It must be closed with Close once it is not used anymore.
+func NewAsyncEmitter(emitter Emitter, config EmitterConfig) *AsyncEmitter {
+	if config.SampleRate == 0 {
+		config.SampleRate = 1
+	}
+
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1024
+	}
+
+	a := &AsyncEmitter{
+		emitter: emitter,
+		config:  config,
+		events:  make(chan FilterEvent, config.BufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go a.run()
+
+	return a
+}
+
+// WithEmitter configures the parser to publish a filter event for every parsed option with the given emitter.
+func WithEmitter(emitter *AsyncEmitter) ParserOption {
+	return func(c *config) {
+		c.emitter = emitter
+	}
+}
+
+// Close stops accepting events and waits until the buffered events are published.
+func (a *AsyncEmitter) Close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.events)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+}
+
+// publish samples and buffers the event, dropping it if the buffer is full or the emitter is closed.
+func (a *AsyncEmitter) publish(event FilterEvent) {
+	if a.config.SampleRate < 1 && rand.Float64() >= a.config.SampleRate {
+		return
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return
+	}
+
+	select {
+	case a.events <- event:
+	default:
+	}
+}
+
+// run publishes the buffered events until the emitter is closed.
+func (a *AsyncEmitter) run() {
+	defer close(a.done)
+
+	for event := range a.events {
+		if err := a.emitter.Emit(context.Background(), event); err != nil && a.config.OnError != nil {
+			a.config.OnError(err)
+		}
+	}
+}
+
+// emit publishes the filter event of the options with the emitter of the parser, if any.
+func (o *Options) emit() {
+	if o.config == nil || o.config.emitter == nil {
+		return
+	}
+
+	event := FilterEvent{
+		Time:    time.Now(),
+		Fields:  make([]FilterEventField, 0, len(o.fields)),
+		Sorts:   make([]string, 0, len(o.sorts)),
+		Selects: o.selects,
+		Limit:   o.limit,
+		Offset:  o.offset,
+	}
+
+	for _, field := range o.fields {
+		event.Fields = append(event.Fields, FilterEventField{
+			Name:     field.Name,
+			Operator: revertOperator(field.Operator),
+			Table:    field.Table,
+			Class:    field.Class,
+		})
+	}
+
+	for _, sort := range o.sorts {
+		if sort.Desc {
+			event.Sorts = append(event.Sorts, "-"+sort.Column)
+			continue
+		}
+
+		event.Sorts = append(event.Sorts, sort.Column)
+	}
+
+	o.config.emitter.publish(event)
+}
@@ -0,0 +1,94 @@
+package qparser
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Encrypter transforms the plaintext value of a filter into the form stored in an encrypted or tokenized column.
+// It must be deterministic, i.e. always return the same ciphertext for the same plaintext,
+// so the encrypted value can be compared with the stored one.
+type Encrypter func(plaintext string) (string, error)
+
+// BlindIndex returns an Encrypter replacing every value by its hex encoded HMAC-SHA256 under the given key,
+// for columns storing a blind index of an application-encrypted value next to its ciphertext.
+func BlindIndex(key []byte) Encrypter {
+	return func(plaintext string) (string, error) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(plaintext))
+
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	}
+}
+
+// WithEncryptedField configures the parser to encrypt the values of filters on the given field before binding them,
+// e.g. for an email column encrypted deterministically by the application.
+// Since only equality is preserved by deterministic encryption, the field only supports the
+// "eq", "neq", "anyof" and "allof" operators, and each element of the latter is encrypted.
+// The encrypted values are the values of the options, so they are also the ones logged, audited and serialized.
+func WithEncryptedField(name string, encrypt Encrypter) ParserOption {
+	return func(c *config) {
+		c.encryptions[name] = encrypt
+	}
+}
+
+// encrypter returns the encrypter of the given field, or nil if its values are not encrypted or already are.
+// Operators which cannot be evaluated on encrypted values result in an error.
+func (o *Options) encrypter(field *Field) (Encrypter, error) {
+	if o.config == nil {
+		return nil, nil
+	}
+
+	encrypt, ok := o.config.encryptions[field.Name]
+	if !ok {
+		return nil, nil
+	}
+
+	switch field.Operator {
+	case sqlOperatorEqual, sqlOperatorNotEqual, sqlOperatorAnyOf, sqlOperatorAllOf:
+	default:
+		return nil, fmt.Errorf("operator %s is not supported for encrypted field %s", revertOperator(field.Operator), field.Name)
+	}
+
+	if field.Encrypted {
+		return nil, nil
+	}
+
+	return encrypt, nil
+}
+
+// encryptField replaces the value and the elements of the given field by their encrypted form,
+// and marks the field as encrypted so serialized options are not encrypted twice once deserialized.
+func encryptField(field *Field, encrypt Encrypter) error {
+	field.Encrypted = true
+
+	if field.Values != nil {
+		values := make([]string, 0, len(field.Values))
+
+		for _, value := range field.Values {
+			encrypted, err := encrypt(value)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt %s: %w", field.Name, err)
+			}
+
+			values = append(values, encrypted)
+		}
+
+		field.Values = values
+		field.Value = strings.Join(values, ",")
+
+		return nil
+	}
+
+	encrypted, err := encrypt(field.Value)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", field.Name, err)
+	}
+
+	field.Value = encrypted
+
+	return nil
+}
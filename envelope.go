@@ -0,0 +1,120 @@
+package qparser
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Page describes the page of results wrapped by Envelope.
+// Total is the number of rows matching the filters, e.g. returned by Options.Count, or nil if it is unknown.
+// URL is the URL of the current request, which the links to the next and previous pages are built from,
+// no links are built when it is nil.
+// Cursor is the cursor continuing after the last row for keyset pagination and incremental sync, see Options.NextCursor,
+// the next link then continues after it instead of increasing the offset.
+type Page struct {
+	Total  *int64
+	URL    *url.URL
+	Cursor string
+}
+
+// EnvelopeMeta describes the pagination and sorting of the data of an EnvelopeResponse.
+type EnvelopeMeta struct {
+	Total  *int64   `json:"total,omitempty"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+	Sort   []string `json:"sort,omitempty"`
+}
+
+// EnvelopeLinks are the links to the next and previous pages of an EnvelopeResponse, empty when there is none.
+type EnvelopeLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// EnvelopeResponse is a uniform JSON payload for lists of results, see Envelope.
+type EnvelopeResponse[T any] struct {
+	Data  []T           `json:"data"`
+	Meta  EnvelopeMeta  `json:"meta"`
+	Links EnvelopeLinks `json:"links"`
+}
+
+// Envelope wraps a page of items queried with the given options into a uniform response,
+// with the total, limit, offset and sort columns in its meta and the links to the next and previous pages:
+//
+//	{"data": [...], "meta": {"total": 120, "limit": 20, "offset": 40, "sort": ["-created_at"]}, "links": {"next": "...", "prev": "..."}}
+//
+// The next link is set when more rows follow, according to the total if it is known,
+// and otherwise when the page is full. The previous link is set when the offset is positive,
+// pages continuing after a cursor have no previous link.
+func Envelope[T any](items []T, page Page, opts *Options) EnvelopeResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	response := EnvelopeResponse[T]{
+		Data: items,
+		Meta: EnvelopeMeta{
+			Total:  page.Total,
+			Limit:  opts.limit,
+			Offset: opts.offset,
+		},
+	}
+
+	if opts.since != nil || opts.after != nil {
+		response.Meta.Offset = 0
+	}
+
+	for _, sort := range opts.sorts {
+		if sort.Desc {
+			response.Meta.Sort = append(response.Meta.Sort, "-"+sort.Column)
+			continue
+		}
+
+		response.Meta.Sort = append(response.Meta.Sort, sort.Column)
+	}
+
+	if page.URL == nil || opts.limit == 0 {
+		return response
+	}
+
+	more := len(items) == opts.limit
+	if page.Total != nil && page.Cursor == "" {
+		more = int64(response.Meta.Offset+len(items)) < *page.Total
+	}
+
+	if more {
+		switch {
+		case page.Cursor != "" && opts.since != nil:
+			response.Links.Next = pageLink(page.URL, "modified_since", page.Cursor)
+		case page.Cursor != "":
+			response.Links.Next = pageLink(page.URL, "after", page.Cursor)
+		default:
+			response.Links.Next = pageLink(page.URL, "offset", strconv.Itoa(response.Meta.Offset+opts.limit))
+		}
+	}
+
+	if response.Meta.Offset > 0 {
+		response.Links.Prev = pageLink(page.URL, "offset", strconv.Itoa(max(response.Meta.Offset-opts.limit, 0)))
+	}
+
+	return response
+}
+
+// pageLink returns the given URL with the given pagination parameter set, replacing the other ones.
+// A zero offset is dropped.
+func pageLink(base *url.URL, name, value string) string {
+	link := *base
+
+	query := link.Query()
+	for _, parameter := range []string{"offset", "after", "modified_since"} {
+		query.Del(parameter)
+	}
+
+	if name != "offset" || value != "0" {
+		query.Set(name, value)
+	}
+
+	link.RawQuery = query.Encode()
+
+	return link.String()
+}
@@ -0,0 +1,165 @@
+package qparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// listFilter is a filter object of a filter list, see ParseFilterListContext.
+type listFilter struct {
+	Field string          `json:"field"`
+	Op    string          `json:"op"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ParseFilterList parses the filter list read from r without a user.
+// See ParseFilterListContext for the details.
+func (p *Parser) ParseFilterList(r io.Reader, params url.Values) (*Options, error) {
+	return p.ParseFilterListContext(context.Background(), r, params)
+}
+
+// ParseFilterListContext parses a filter list read from r, the JSON array of filter objects
+// most filter builder components emit, according to the schema of the parser, e.g.
+//
+//	[{"field": "age", "op": "gte", "value": 18}, {"field": "name", "op": "like", "value": "bob"}]
+//
+// The operator is in query form and defaults to "eq". The value is a string, a number or a bool,
+// or an array of them for the "anyof", "allof" and "words" operators, and the two bounds of the "rng" operator.
+// The other parameters of ParseValuesContext, like "sort" and "limit", are taken from the given params,
+// e.g. the query of the request, which may be nil.
+// The list is decoded filter by filter and rejected as soon as it exceeds the limits of the parser,
+// see WithDocumentLimits, or uses a field missing from the schema.
+// The filters are then parsed like query values, sharing all of their validation, see ParseValuesContext.
+func (p *Parser) ParseFilterListContext(ctx context.Context, r io.Reader, params url.Values) (*Options, error) {
+	cfg := p.config.Load()
+
+	if cfg.schema == nil {
+		return nil, fmt.Errorf("parser has no schema")
+	}
+
+	limits := cfg.documentLimits.withDefaults()
+
+	known := make(map[string]struct{}, len(cfg.schema.Fields))
+	for _, schemaField := range cfg.schema.Fields {
+		known[schemaField.Name] = struct{}{}
+	}
+
+	values := make(url.Values, len(params))
+	for name, queries := range params {
+		values[name] = append([]string(nil), queries...)
+	}
+
+	decoder := json.NewDecoder(&limitedReader{reader: r, remaining: limits.MaxBytes, limit: limits.MaxBytes})
+	decoder.UseNumber()
+
+	if err := expectDelim(decoder, '['); err != nil {
+		return nil, err
+	}
+
+	filters := 0
+
+	for decoder.More() {
+		var filter listFilter
+
+		if err := decoder.Decode(&filter); err != nil {
+			return nil, documentError(err)
+		}
+
+		if filters++; filters > limits.MaxFilters {
+			return nil, fmt.Errorf("filter list has more than %d filters", limits.MaxFilters)
+		}
+
+		name := cfg.canonicalName(filter.Field)
+		if _, ok := known[name]; !ok {
+			return nil, cfg.unknownField(RoleFromContext(ctx), filter.Field)
+		}
+
+		op := filter.Op
+		if op == "" {
+			op = operatorEqual
+		}
+
+		value, err := listValue(op, filter.Value)
+		if err != nil {
+			return nil, fmt.Errorf("bad value of %s: %w", filter.Field, err)
+		}
+
+		if len(value) > limits.MaxValueLength {
+			return nil, fmt.Errorf("value of %s exceeds %d bytes", filter.Field, limits.MaxValueLength)
+		}
+
+		values.Add(name, op+":"+value)
+	}
+
+	if err := expectDelim(decoder, ']'); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("bad filter document, unexpected data after the array")
+	}
+
+	return p.ParseValuesContext(ctx, values)
+}
+
+// listValue returns the query value of the value of a filter object with the given operator.
+// Arrays are joined in the form of the operator, e.g. "18 to 65" for "rng".
+func listValue(op string, raw json.RawMessage) (string, error) {
+	var value interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("use a string, a number, a bool or an array of them")
+	}
+
+	elements, ok := value.([]interface{})
+	if !ok {
+		return listScalar(value)
+	}
+
+	values := make([]string, 0, len(elements))
+
+	for _, element := range elements {
+		s, err := listScalar(element)
+		if err != nil {
+			return "", err
+		}
+
+		values = append(values, s)
+	}
+
+	switch op {
+	case operatorRange:
+		if len(values) != 2 {
+			return "", fmt.Errorf("rng requires two bounds")
+		}
+
+		return values[0] + " to " + values[1], nil
+	case operatorAnyOf, operatorAllOf:
+		return strings.Join(values, ","), nil
+	case operatorWords:
+		return strings.Join(values, " "), nil
+	default:
+		return "", fmt.Errorf("operator %s does not accept an array", op)
+	}
+}
+
+// listScalar returns the query value of a scalar value of a filter object.
+func listScalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("use a string, a number, a bool or an array of them")
+	}
+}
@@ -0,0 +1,48 @@
+package qparser
+
+import "gorm.io/gorm"
+
+// Scope returns the options as a scope for the GORM generics API, applying them like Apply does:
+//
+//	users, err := gorm.G[User](db).Scopes(options.Scope()).Find(ctx)
+//
+// The generics API runs scopes on a statement which they must modify in place, see applyStatement.
+func (o *Options) Scope() func(*gorm.Statement) {
+	return func(stmt *gorm.Statement) {
+		applyStatement(stmt, o.Apply)
+	}
+}
+
+// FilterScope returns the filters of the options as a scope for the GORM generics API,
+// without sorting or pagination, e.g. for counting the matching rows:
+//
+//	total, err := gorm.G[User](db).Scopes(options.FilterScope()).Count(ctx, "*")
+func (o *Options) FilterScope() func(*gorm.Statement) {
+	return func(stmt *gorm.Statement) {
+		applyStatement(stmt, o.filter)
+	}
+}
+
+// applyStatement applies the given function to a session cloning the statement, and copies the clauses, joins,
+// selected columns, preloads, tables, settings and error of the returned instance back into the statement,
+// since GORM returns new instances instead of modifying the statement whenever its database is not a session yet,
+// e.g. in dry run mode. Scopes added to the returned instance itself with Scopes are not copied.
+func applyStatement(stmt *gorm.Statement, apply func(*gorm.DB) *gorm.DB) {
+	tx := apply(stmt.DB.Session(&gorm.Session{}))
+
+	stmt.Clauses = tx.Statement.Clauses
+	stmt.Joins = tx.Statement.Joins
+	stmt.Selects = tx.Statement.Selects
+	stmt.Omits = tx.Statement.Omits
+	stmt.Distinct = tx.Statement.Distinct
+	stmt.Preloads = tx.Statement.Preloads
+	stmt.Table = tx.Statement.Table
+	stmt.TableExpr = tx.Statement.TableExpr
+	stmt.Unscoped = tx.Statement.Unscoped
+	stmt.Error = tx.Error
+
+	tx.Statement.Settings.Range(func(key, value interface{}) bool {
+		stmt.Settings.Store(key, value)
+		return true
+	})
+}
@@ -0,0 +1,92 @@
+package qparser
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+type genericUser struct {
+	ID   uint
+	Name string
+	Age  int
+}
+
+// dryRunDB returns a dry run session recording the SQL of every query and count.
+func dryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queries []string
+
+	if err := db.Callback().Query().After("gorm:query").Register("qparser:record", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db.Session(&gorm.Session{DryRun: true}), &queries
+}
+
+func TestGenericScopes(t *testing.T) {
+	p := NewParser(WithSchema(&Schema{
+		Fields:   []SchemaField{{Name: "name"}, {Name: "age"}},
+		Sortable: []string{"age"},
+	}))
+
+	opts, err := p.ParseValues(url.Values{"name": {"eq:alice"}, "age": {"gt:21"}, "sort": {"-age"}, "limit": {"5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		run  func(db *gorm.DB) error
+		want []string
+	}{
+		{
+			name: "scope",
+			run: func(db *gorm.DB) error {
+				_, err := gorm.G[genericUser](db).Scopes(opts.Scope()).Find(context.Background())
+				return err
+			},
+			want: []string{"WHERE name = ? AND age > ?", "ORDER BY `age` DESC LIMIT ?"},
+		},
+		{
+			name: "filter scope",
+			run: func(db *gorm.DB) error {
+				_, err := gorm.G[genericUser](db).Scopes(opts.FilterScope()).Count(context.Background(), "*")
+				return err
+			},
+			want: []string{"WHERE name = ? AND age > ?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, queries := dryRunDB(t)
+
+			if err := tt.run(db); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(*queries) != 1 {
+				t.Fatalf("expected a single query, got %q", *queries)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains((*queries)[0], want) {
+					t.Errorf("query %q does not contain %q", (*queries)[0], want)
+				}
+			}
+		})
+	}
+}
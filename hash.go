@@ -0,0 +1,75 @@
+package qparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Hash returns a stable hash of the filters, sorting, selected columns and pagination of the options.
+// Options describing the same query have the same hash, regardless of the order their fields were added in,
+// since the fields are combined with AND.
+// The user of the options is not part of the hash, CachedFind and Group add it to their keys themselves.
+func (o *Options) Hash() string {
+	fields := make([]string, 0, len(o.fields))
+
+	for _, field := range o.fields {
+		value := field.Value
+		if len(field.Values) > 0 {
+			value = strings.Join(field.Values, "|")
+		}
+
+		fields = append(fields, fmt.Sprintf("%q %q %q %q %t", field.Table, field.Name, field.Operator, value, field.Fold))
+	}
+
+	sort.Strings(fields)
+
+	sorts := make([]string, 0, len(o.sorts))
+
+	for _, s := range o.sorts {
+		sorts = append(sorts, fmt.Sprintf("%q %t", s.Column, s.Desc))
+	}
+
+	h := sha256.New()
+
+	fmt.Fprintf(h, "fields:%s\n", strings.Join(fields, ","))
+	fmt.Fprintf(h, "sorts:%s\n", strings.Join(sorts, ","))
+	fmt.Fprintf(h, "selects:%q\n", o.selects)
+	fmt.Fprintf(h, "limit:%d offset:%d\n", o.limit, o.offset)
+
+	if o.since != nil {
+		fmt.Fprintf(h, "modified_since:%q\n", o.since.raw)
+	}
+
+	if o.after != nil {
+		fmt.Fprintf(h, "after:%q\n", o.after.raw)
+	}
+
+	if len(o.includes) > 0 {
+		includes := make([]string, 0, len(o.includes))
+
+		for _, include := range o.includes {
+			includes = append(includes, fmt.Sprintf("%q %s", include.association, include.options.Hash()))
+		}
+
+		fmt.Fprintf(h, "includes:%s\n", strings.Join(includes, ","))
+	}
+
+	if o.unscoped {
+		fmt.Fprintf(h, "include_deleted\n")
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// principal returns the user the options were parsed for as a string, identifying the caller
+// in the keys of results that must not be shared between users, or an empty string without user.
+func (o *Options) principal() string {
+	if o.user == nil {
+		return ""
+	}
+
+	return fmt.Sprint(o.user)
+}
@@ -0,0 +1,193 @@
+package qparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Include describes an association clients may preload with the "include" parameter, see WithInclude.
+// Association is the GORM association preloaded for it, e.g. "Orders", and defaults to the name of the include.
+// Schema declares the filters clients may apply to the preloaded rows, with the fields and operators it allows,
+// the include accepts no filters when it is nil.
+type Include struct {
+	Association string
+	Schema      *Schema
+}
+
+// WithInclude registers an association clients may preload with the "include" parameter of ParseValues.
+// The parameter is a comma-separated list of includes, each one optionally followed by
+// semicolon-separated filters in parentheses, e.g. "include=orders(status=eq:paid;total=gte:100),profile".
+// The filters are parsed like query values according to the schema of the include,
+// and applied by Apply as the conditions of the preload, so they shape which associated rows are returned.
+func WithInclude(name string, include Include) ParserOption {
+	return func(c *config) {
+		if include.Association == "" {
+			include.Association = name
+		}
+
+		if include.Schema == nil {
+			include.Schema = &Schema{}
+		}
+
+		c.includes[name] = &includeConfig{
+			association: include.Association,
+			config:      newConfig([]ParserOption{WithSchema(include.Schema)}),
+		}
+	}
+}
+
+// includeConfig is an include registered on the parser, with the configuration its filters are parsed with.
+type includeConfig struct {
+	association string
+	config      *config
+}
+
+// include is an association preloaded by the options, with the options filtering its rows.
+type include struct {
+	association string
+	options     *Options
+}
+
+// setIncludes parses the value of the "include" parameter and sets the includes of the options.
+func (o *Options) setIncludes(value string) error {
+	if o.config == nil {
+		return fmt.Errorf("parser has no includes")
+	}
+
+	parts, err := splitIncludes(value)
+	if err != nil {
+		return err
+	}
+
+	includes := make([]include, 0, len(parts))
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+
+		name, filters, hasFilters := strings.Cut(part, "(")
+		if hasFilters && !strings.HasSuffix(filters, ")") {
+			return fmt.Errorf("bad include %s, filters must end the include", part)
+		}
+
+		name = strings.TrimSpace(name)
+
+		cfg, ok := o.config.includes[name]
+		if !ok {
+			names := make([]string, 0, len(o.config.includes))
+			for registered := range o.config.includes {
+				names = append(names, registered)
+			}
+
+			sort.Strings(names)
+
+			return reject(ReasonIncludeNotAllowed, name, "", names, fmt.Sprintf("include %s is not allowed", name))
+		}
+
+		options, err := parseIncludeFilters(cfg.config, strings.TrimSuffix(filters, ")"))
+		if err != nil {
+			return fmt.Errorf("bad filters of include %s: %w", name, err)
+		}
+
+		includes = append(includes, include{association: cfg.association, options: options})
+	}
+
+	o.includes = includes
+	o.include = value
+
+	return nil
+}
+
+// splitIncludes splits the value of the "include" parameter on the commas outside of parentheses.
+func splitIncludes(value string) ([]string, error) {
+	parts := make([]string, 0)
+	depth, start := 0, 0
+
+	for i, r := range value {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("bad include %s, unbalanced parentheses", value)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("bad include %s, unbalanced parentheses", value)
+	}
+
+	return append(parts, value[start:]), nil
+}
+
+// parseIncludeFilters parses the semicolon-separated filters of an include, e.g. "status=eq:paid;total=gte:100",
+// according to the schema of the given include configuration.
+func parseIncludeFilters(cfg *config, filters string) (*Options, error) {
+	opt := newOptions(cfg)
+
+	if strings.TrimSpace(filters) == "" {
+		return opt, nil
+	}
+
+	for _, filter := range strings.Split(filters, ";") {
+		name, query, ok := strings.Cut(filter, "=")
+		if !ok || query == "" {
+			return nil, fmt.Errorf("bad filter %s, use name=operator:value", filter)
+		}
+
+		name = strings.TrimSpace(name)
+
+		var schemaField *SchemaField
+
+		for i := range cfg.schema.Fields {
+			if cfg.schema.Fields[i].Name == name {
+				schemaField = &cfg.schema.Fields[i]
+			}
+		}
+
+		if schemaField == nil {
+			return nil, cfg.unknownField("", name)
+		}
+
+		field, err := parseQuery(schemaField.column(), query)
+		if err != nil {
+			return nil, err
+		}
+
+		if !schemaField.allows(field.Operator) {
+			return nil, schemaField.operatorNotAllowed(field.Operator)
+		}
+
+		field.Class = schemaField.Class
+		field.Table = schemaField.Table
+		field.Fold = schemaField.Fold
+
+		if err := opt.addField(field); err != nil {
+			return nil, err
+		}
+	}
+
+	return opt, nil
+}
+
+// preload preloads the includes of the options on the given GORM transaction, filtering their rows.
+func (o *Options) preload(tx *gorm.DB) *gorm.DB {
+	for _, include := range o.includes {
+		if len(include.options.fields) == 0 {
+			tx = tx.Preload(include.association)
+			continue
+		}
+
+		tx = tx.Preload(include.association, include.options.filter)
+	}
+
+	return tx
+}
@@ -0,0 +1,137 @@
+package integrationtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	gormmysql "gorm.io/driver/mysql"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The images started by Postgres and MySQL. MySQL must be 8.0.17 or later, which added JSON_OVERLAPS.
+const (
+	PostgresImage = "postgres:16-alpine"
+	MySQLImage    = "mysql:8.0"
+)
+
+// startupTimeout is how long the containers may take to accept connections.
+const startupTimeout = 2 * time.Minute
+
+// Postgres starts a postgres container for the test and returns a database connected to it.
+// The test is skipped in short mode and when Docker is not available,
+// and the container is terminated once the test is done.
+func Postgres(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	skipWithoutDocker(t)
+
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx, PostgresImage,
+		postgres.WithDatabase("qparser"),
+		postgres.WithUsername("qparser"),
+		postgres.WithPassword("qparser"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2).WithStartupTimeout(startupTimeout),
+		),
+	)
+	if container != nil {
+		terminate(t, container)
+	}
+
+	if err != nil {
+		t.Fatalf("failed to start postgres: %v", err)
+	}
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("failed to get the postgres DSN: %v", err)
+	}
+
+	return open(t, gormpostgres.Open(dsn))
+}
+
+// MySQL starts a mysql container for the test and returns a database connected to it.
+// The test is skipped in short mode and when Docker is not available,
+// and the container is terminated once the test is done.
+func MySQL(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	skipWithoutDocker(t)
+
+	ctx := context.Background()
+
+	container, err := mysql.Run(ctx, MySQLImage,
+		mysql.WithDatabase("qparser"),
+		mysql.WithUsername("qparser"),
+		mysql.WithPassword("qparser"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("port: 3306  MySQL Community Server").WithStartupTimeout(startupTimeout),
+		),
+	)
+	if container != nil {
+		terminate(t, container)
+	}
+
+	if err != nil {
+		t.Fatalf("failed to start mysql: %v", err)
+	}
+
+	dsn, err := container.ConnectionString(ctx, "parseTime=true")
+	if err != nil {
+		t.Fatalf("failed to get the mysql DSN: %v", err)
+	}
+
+	return open(t, gormmysql.Open(dsn))
+}
+
+// skipWithoutDocker skips the test in short mode and when Docker is not available.
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("database containers are not started in short mode")
+	}
+
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+// terminate terminates the given container once the test is done,
+// including containers which were created but failed to start.
+func terminate(t *testing.T, container testcontainers.Container) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Errorf("failed to terminate container: %v", err)
+		}
+	})
+}
+
+// open opens a database with the given dialector, closing it once the test is done.
+func open(t *testing.T, dialector gorm.Dialector) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get the connection pool: %v", err)
+	}
+
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+
+	return db
+}
@@ -0,0 +1,255 @@
+// Package integrationtest asserts the end-to-end behavior of the qparser operators against a real database.
+//
+// Postgres and MySQL start a database in a container with testcontainers, skipping the test without Docker,
+// and Run executes every case against it:
+//
+//	func TestPostgres(t *testing.T) {
+//		integrationtest.Run(t, integrationtest.Postgres(t))
+//	}
+//
+// Any other *gorm.DB can be passed to Run as well, e.g. opened with the DSN of an existing database:
+//
+//	func TestStaging(t *testing.T) {
+//		db, err := gorm.Open(postgres.Open(integrationtest.RequireDSN(t, "QPARSER_POSTGRES_DSN")))
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//
+//		integrationtest.Run(t, db)
+//	}
+//
+// The package is a separate module, so applications importing qparser do not depend on testcontainers,
+// and it builds against the library of the same checkout.
+package integrationtest
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0x16F/qparser"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Row is the model filtered by the cases of the kit.
+type Row struct {
+	ID        uint `gorm:"primaryKey"`
+	Name      string
+	Age       int
+	Tags      Tags
+	CreatedAt time.Time
+}
+
+// TableName returns the table of the rows.
+func (Row) TableName() string {
+	return "qparser_rows"
+}
+
+// Tags is a JSON array of strings, it backs the "anyof" and "allof" cases.
+// It is stored as jsonb on postgres, json on mysql and text on other databases.
+type Tags []string
+
+// Value encodes the tags as a JSON array.
+func (t Tags) Value() (driver.Value, error) {
+	encoded, err := json.Marshal([]string(t))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+// Scan decodes the tags from a JSON array.
+func (t *Tags) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case nil:
+		*t = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported tags type %T", src)
+	}
+}
+
+// GormDBDataType returns the column type of the tags for the dialect of the database.
+func (Tags) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "jsonb"
+	case "mysql":
+		return "json"
+	default:
+		return "text"
+	}
+}
+
+// Child is related to a Row, it backs the relation and count filter cases.
+type Child struct {
+	ID    uint `gorm:"primaryKey"`
+	RowID uint
+}
+
+// TableName returns the table of the children.
+func (Child) TableName() string {
+	return "qparser_children"
+}
+
+// Case is a query executed against the seeded rows and the names of the rows it must return.
+// If the query is sorted, the names must be returned in the same order.
+type Case struct {
+	Name  string
+	Query string
+	Want  []string
+}
+
+// Cases are the cases executed by Run.
+// The seeded rows are alice (25, 2020-06-01, tagged go and sql, 2 children), bob (35, 2021-06-01, tagged go, 1 child)
+// and carol (45, 2022-06-01, tagged rust, no children).
+var Cases = []Case{
+	{Name: "equal", Query: "name=eq:alice", Want: []string{"alice"}},
+	{Name: "not equal", Query: "name=neq:alice&sort=name", Want: []string{"bob", "carol"}},
+	{Name: "greater than", Query: "age=gt:35", Want: []string{"carol"}},
+	{Name: "greater than or equal", Query: "age=gte:35&sort=name", Want: []string{"bob", "carol"}},
+	{Name: "lower than", Query: "age=lt:35", Want: []string{"alice"}},
+	{Name: "lower than or equal", Query: "age=lte:35&sort=name", Want: []string{"alice", "bob"}},
+	{Name: "like is case-insensitive", Query: "name=like:AL", Want: []string{"alice"}},
+	{Name: "range on numbers", Query: "age=rng:30 to 40", Want: []string{"bob"}},
+	{Name: "range on dates", Query: "created_at=rng:2020-01-01 to 2020-12-31", Want: []string{"alice"}},
+	{Name: "any of array elements", Query: "tags=anyof:sql,rust&sort=name", Want: []string{"alice", "carol"}},
+	{Name: "any of a single array element", Query: "tags=anyof:go&sort=name", Want: []string{"alice", "bob"}},
+	{Name: "all of array elements", Query: "tags=allof:go,sql", Want: []string{"alice"}},
+	{Name: "all of array elements without match", Query: "tags=allof:go,rust", Want: []string{}},
+	{Name: "has relation", Query: "relations=has:children&sort=name", Want: []string{"alice", "bob"}},
+	{Name: "has not relation", Query: "relations=hasnot:children", Want: []string{"carol"}},
+	{Name: "count filter", Query: "child_count=gte:2", Want: []string{"alice"}},
+	{Name: "count filter with zero", Query: "child_count=lt:1", Want: []string{"carol"}},
+	{Name: "sort and limit", Query: "sort=-age&limit=2", Want: []string{"carol", "bob"}},
+	{Name: "offset", Query: "sort=age&limit=1&offset=1", Want: []string{"bob"}},
+}
+
+// RequireDSN returns the DSN stored in the given environment variable,
+// skipping the test if it is not set so the kit stays optional in local runs.
+func RequireDSN(t testing.TB, env string) string {
+	t.Helper()
+
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		t.Skipf("%s is not set", env)
+	}
+
+	return dsn
+}
+
+// NewParser returns the parser used by Run.
+func NewParser() *qparser.Parser {
+	relation := qparser.Relation{
+		Table:      "qparser_children",
+		ForeignKey: "row_id",
+		References: "qparser_rows.id",
+	}
+
+	return qparser.NewParser(
+		qparser.WithSchema(&qparser.Schema{
+			Fields: []qparser.SchemaField{
+				{Name: "name"},
+				{Name: "age"},
+				{Name: "created_at"},
+				{Name: "tags", Operators: []string{"anyof", "allof"}},
+				{Name: "relations", Operators: []string{"has", "hasnot"}},
+				{Name: "child_count"},
+			},
+			Sortable: []string{"name", "age"},
+		}),
+		qparser.WithRelation("children", relation),
+		qparser.WithCountFilter("child_count", relation),
+	)
+}
+
+// Run migrates and seeds the tables of the kit, executes every case of Cases against them
+// and drops the tables once the test is done.
+func Run(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	seed(t, db)
+
+	parser := NewParser()
+
+	for _, c := range Cases {
+		c := c
+
+		t.Run(c.Name, func(t *testing.T) {
+			values, err := url.ParseQuery(c.Query)
+			if err != nil {
+				t.Fatalf("failed to parse query %q: %v", c.Query, err)
+			}
+
+			options, err := parser.ParseValues(values)
+			if err != nil {
+				t.Fatalf("failed to parse values %q: %v", c.Query, err)
+			}
+
+			var rows []Row
+
+			if err := options.Apply(db.Model(&Row{})).Find(&rows).Error; err != nil {
+				t.Fatalf("failed to execute %q: %v", c.Query, err)
+			}
+
+			got := make([]string, 0, len(rows))
+			for _, row := range rows {
+				got = append(got, row.Name)
+			}
+
+			if !reflect.DeepEqual(got, c.Want) {
+				t.Errorf("%q returned %v, want %v", c.Query, got, c.Want)
+			}
+		})
+	}
+}
+
+// seed creates the tables of the kit and inserts the rows described by Cases.
+func seed(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	if err := db.Migrator().DropTable(&Child{}, &Row{}); err != nil {
+		t.Fatalf("failed to drop tables: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Row{}, &Child{}); err != nil {
+		t.Fatalf("failed to migrate tables: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := db.Migrator().DropTable(&Child{}, &Row{}); err != nil {
+			t.Errorf("failed to drop tables: %v", err)
+		}
+	})
+
+	rows := []Row{
+		{Name: "alice", Age: 25, Tags: Tags{"go", "sql"}, CreatedAt: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "bob", Age: 35, Tags: Tags{"go"}, CreatedAt: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "carol", Age: 45, Tags: Tags{"rust"}, CreatedAt: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("failed to seed rows: %v", err)
+	}
+
+	children := []Child{
+		{RowID: rows[0].ID},
+		{RowID: rows[0].ID},
+		{RowID: rows[1].ID},
+	}
+
+	if err := db.Create(&children).Error; err != nil {
+		t.Fatalf("failed to seed children: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package integrationtest_test
+
+import (
+	"testing"
+
+	"github.com/0x16F/qparser/integrationtest"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:qparser?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	integrationtest.Run(t, db)
+}
+
+func TestPostgres(t *testing.T) {
+	integrationtest.Run(t, integrationtest.Postgres(t))
+}
+
+func TestMySQL(t *testing.T) {
+	integrationtest.Run(t, integrationtest.MySQL(t))
+}
@@ -0,0 +1,395 @@
+package qparser
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var matchSchemas sync.Map
+
+// matchTimeLayouts are the layouts filter values are parsed with when compared with time values.
+var matchTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// Match reports whether the given row satisfies the filters of the options, evaluated in memory
+// instead of by the database, e.g. to test new or changed rows against saved filters.
+// The row is a map keyed by column, or a struct or a pointer to a struct whose columns are resolved
+// with the default GORM naming strategy. Fields owned by a table are looked up by their qualified name first.
+// Rows soft-deleted with gorm.DeletedAt never match, unless the options include them, see IncludeDeleted.
+// Comparisons follow SQL semantics: NULL or missing values never match, "like" is case-insensitive,
+// strings are compared lexicographically, and numbers, booleans and times by value.
+// Periods are resolved to the current one whenever the row is matched, like Apply does, see periodCondition.
+// Relation and count filter conditions cannot be evaluated in memory and result in an error.
+func (o *Options) Match(row interface{}) (bool, error) {
+	lookup, deleted, err := rowLookup(row)
+	if err != nil {
+		return false, err
+	}
+
+	if deleted && !o.unscoped {
+		return false, nil
+	}
+
+	for _, field := range o.fields {
+		if _, ok := o.countFilter(field); ok {
+			return false, fmt.Errorf("count filter %s cannot be matched in memory", field.Name)
+		}
+
+		if field.Operator == sqlOperatorExists || field.Operator == sqlOperatorNotExists {
+			return false, fmt.Errorf("relation filter %s cannot be matched in memory", field.Value)
+		}
+
+		if field.Operator == sqlOperatorInSubquery || field.Operator == sqlOperatorNotInSubquery {
+			return false, fmt.Errorf("subquery filter %s cannot be matched in memory", field.Value)
+		}
+
+		value, ok := lookup(field.Name)
+		if field.Table != "" {
+			if qualified, found := lookup(field.Table + "." + field.Name); found {
+				value, ok = qualified, true
+			}
+		}
+
+		if !ok {
+			return false, nil
+		}
+
+		var matched bool
+		if field.Operator == sqlOperatorPeriod {
+			matched, err = o.matchPeriod(field, value)
+		} else {
+			matched, err = matchField(field, value)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// rowLookup returns a function looking up the values of the columns of the given row,
+// and whether the row is a struct soft-deleted with a gorm.DeletedAt field.
+func rowLookup(row interface{}) (func(column string) (interface{}, bool), bool, error) {
+	if values, ok := row.(map[string]interface{}); ok {
+		return func(column string) (interface{}, bool) {
+			value, ok := values[column]
+
+			return value, ok
+		}, false, nil
+	}
+
+	rowValue := reflect.ValueOf(row)
+	for rowValue.Kind() == reflect.Ptr && !rowValue.IsNil() {
+		rowValue = rowValue.Elem()
+	}
+
+	if rowValue.Kind() != reflect.Struct {
+		return nil, false, fmt.Errorf("row must be a map, a struct or a pointer to a struct")
+	}
+
+	rowSchema, err := schema.Parse(row, &matchSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse row: %w", err)
+	}
+
+	deleted := false
+
+	for _, field := range rowSchema.Fields {
+		if field.FieldType != reflect.TypeOf(gorm.DeletedAt{}) {
+			continue
+		}
+
+		if value, zero := field.ValueOf(context.Background(), rowValue); !zero {
+			deleted = value.(gorm.DeletedAt).Valid
+		}
+	}
+
+	return func(column string) (interface{}, bool) {
+		field := rowSchema.LookUpField(column)
+		if field == nil {
+			return nil, false
+		}
+
+		value, _ := field.ValueOf(context.Background(), rowValue)
+
+		return value, true
+	}, deleted, nil
+}
+
+// matchField reports whether the given value of a row satisfies the condition of the field.
+func matchField(field *Field, value interface{}) (bool, error) {
+	value, err := scalarValue(value)
+	if err != nil {
+		return false, err
+	}
+
+	if value == nil {
+		return false, nil
+	}
+
+	switch field.Operator {
+	case sqlOperatorLike:
+		return likePattern(field.Value).MatchString(fmt.Sprint(value)), nil
+	case sqlOperatorWords:
+		for _, word := range field.Values {
+			if !likePattern(word).MatchString(fmt.Sprint(value)) {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	case sqlOperatorRange:
+		for i := 0; i+1 < len(field.Values); i += 2 {
+			lower, err := compareValue(value, field.Values[i])
+			if err != nil {
+				return false, err
+			}
+
+			upper, err := compareValue(value, field.Values[i+1])
+			if err != nil {
+				return false, err
+			}
+
+			if lower >= 0 && upper <= 0 {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case sqlOperatorAnyOf, sqlOperatorAllOf:
+		elements, err := arrayElements(value)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", field.Name, err)
+		}
+
+		for _, element := range field.Values {
+			found := contains(elements, element)
+
+			if found && field.Operator == sqlOperatorAnyOf {
+				return true, nil
+			}
+
+			if !found && field.Operator == sqlOperatorAllOf {
+				return false, nil
+			}
+		}
+
+		return field.Operator == sqlOperatorAllOf, nil
+	}
+
+	comparison, err := compareValue(value, field.Value)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", field.Name, err)
+	}
+
+	switch field.Operator {
+	case sqlOperatorEqual:
+		return comparison == 0, nil
+	case sqlOperatorNotEqual:
+		return comparison != 0, nil
+	case sqlOperatorGreaterThan:
+		return comparison > 0, nil
+	case sqlOperatorGreaterThanEqual:
+		return comparison >= 0, nil
+	case sqlOperatorLowerThan:
+		return comparison < 0, nil
+	case sqlOperatorLowerThanEqual:
+		return comparison <= 0, nil
+	default:
+		return false, fmt.Errorf("operator %s cannot be matched in memory", revertOperator(field.Operator))
+	}
+}
+
+// matchPeriod reports whether the given time value of a row is within the current period of the given field.
+// Values which are not times are parsed in the time zone of the parser.
+func (o *Options) matchPeriod(field *Field, value interface{}) (bool, error) {
+	value, err := scalarValue(value)
+	if err != nil || value == nil {
+		return false, err
+	}
+
+	start, end, err := o.period(field)
+	if err != nil {
+		return false, err
+	}
+
+	t, ok := value.(time.Time)
+	if !ok {
+		parsed, found := parseTime(fmt.Sprint(value), start.Location())
+		if !found {
+			return false, fmt.Errorf("%s: bad time %v", field.Name, value)
+		}
+
+		t = parsed
+	}
+
+	return !t.Before(start) && t.Before(end), nil
+}
+
+// parseTime parses the given value with the first matching layout of matchTimeLayouts,
+// interpreting values without a time zone in the given location.
+func parseTime(value string, location *time.Location) (time.Time, bool) {
+	for _, layout := range matchTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// scalarValue dereferences the given value of a row and resolves values implementing driver.Valuer,
+// e.g. sql.NullString, returning nil for NULL values.
+func scalarValue(value interface{}) (interface{}, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if v := reflect.ValueOf(valuer); v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nil
+		}
+
+		resolved, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		value = resolved
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	return v.Interface(), nil
+}
+
+// compareValue compares the given value of a row with a filter value, both interpreted as the type of the row value.
+// It returns -1, 0 or 1 when the row value is lower than, equal to or greater than the filter value.
+func compareValue(value interface{}, filter string) (int, error) {
+	if t, ok := value.(time.Time); ok {
+		if parsed, ok := parseTime(filter, t.Location()); ok {
+			return t.Compare(parsed), nil
+		}
+
+		return 0, fmt.Errorf("bad time %s", filter)
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(filter)
+		if err != nil {
+			return 0, fmt.Errorf("bad boolean %s", filter)
+		}
+
+		if v.Bool() == b {
+			return 0, nil
+		}
+
+		if b {
+			return -1, nil
+		}
+
+		return 1, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(filter, 64); err != nil {
+			return 0, fmt.Errorf("bad number %s", filter)
+		}
+
+		cmp, _ := orderValues(fmt.Sprint(value), filter)
+
+		return cmp, nil
+	case reflect.Slice:
+		if bytes, ok := value.([]byte); ok {
+			return strings.Compare(string(bytes), filter), nil
+		}
+	case reflect.String:
+		return strings.Compare(v.String(), filter), nil
+	}
+
+	return strings.Compare(fmt.Sprint(value), filter), nil
+}
+
+// arrayElements returns the elements of the given array value of a row,
+// either a slice or a JSON array encoded as a string or bytes.
+func arrayElements(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return jsonElements([]byte(v))
+	case []byte:
+		return jsonElements(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("value is not an array")
+	}
+
+	elements := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elements = append(elements, fmt.Sprint(rv.Index(i).Interface()))
+	}
+
+	return elements, nil
+}
+
+// jsonElements returns the elements of the given JSON array.
+func jsonElements(data []byte) ([]string, error) {
+	var decoded []interface{}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("value is not a JSON array")
+	}
+
+	elements := make([]string, 0, len(decoded))
+	for _, element := range decoded {
+		elements = append(elements, fmt.Sprint(element))
+	}
+
+	return elements, nil
+}
+
+// likePattern returns the case-insensitive regular expression equivalent to the given LIKE pattern,
+// where "%" matches any sequence of characters and "_" a single character.
+func likePattern(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+
+	expr.WriteString("(?is)^")
+
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String())
+}
@@ -0,0 +1,71 @@
+package qparser
+
+// Middleware is a step run on every options parsed by a parser, see Parser.Use.
+// It may modify the options, e.g. add a tenant filter with AddField or clamp the limit with SetLimit,
+// and its error is returned by the parse call instead of the options.
+type Middleware func(opt *Options) error
+
+// Use appends the given middleware to the pipeline of the parser and returns the parser, so calls can be chained.
+// After parsing ParseStruct, ParseValues and ParseJSON, and after deserializing UnmarshalOptions,
+// and so LoadSearch and ApplySearch, run the middleware in the order it was added,
+// stopping at the first error, and before the cost budget, rate limit, audit hooks and emitters,
+// so those observe the options as the middleware left them, as does Apply.
+// The pipeline is kept when the configuration of the parser is reloaded.
+func (p *Parser) Use(middleware ...Middleware) *Parser {
+	for {
+		current := p.middleware.Load()
+
+		var next []Middleware
+		if current != nil {
+			next = append(next, *current...)
+		}
+
+		next = append(next, middleware...)
+
+		if p.middleware.CompareAndSwap(current, &next) {
+			return p
+		}
+	}
+}
+
+// runMiddleware runs the middleware pipeline of the parser on the given options.
+// The fields added by the middleware are mandatory, so they are not serialized with the options.
+func (p *Parser) runMiddleware(opt *Options) error {
+	pipeline := p.middleware.Load()
+	if pipeline == nil {
+		return nil
+	}
+
+	added := len(opt.fields)
+
+	for _, middleware := range *pipeline {
+		if err := middleware(opt); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range opt.fields[min(added, len(opt.fields)):] {
+		field.mandatory = true
+	}
+
+	return nil
+}
+
+// User returns the user the options were parsed for, see ContextWithUser, e.g. for tenancy middleware.
+func (o *Options) User() interface{} {
+	return o.user
+}
+
+// Limit returns the limit of the options, or 0 if they are not limited.
+func (o *Options) Limit() int {
+	return o.limit
+}
+
+// SetLimit sets the limit of the options, e.g. for middleware clamping the page size. 0 removes the limit.
+func (o *Options) SetLimit(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+
+	o.limit = limit
+}
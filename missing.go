@@ -0,0 +1,136 @@
+package qparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrMissingColumn is returned by Apply when a condition which must not be dropped is on a missing column,
+// see WithMissingColumns.
+var ErrMissingColumn = errors.New("column of a mandatory condition is missing")
+
+// MissingColumn describes a condition dropped by Apply because its column does not exist in the connected database,
+// see WithMissingColumns. Table and Column name the missing column, and Field is the dropped filter.
+type MissingColumn struct {
+	Table  string
+	Column string
+	Field  Field
+}
+
+// MissingColumns configures the dropping of conditions on missing columns, see WithMissingColumns.
+// OnMissing is notified of every dropped condition, e.g. to log a warning, and may be nil.
+// TTL is how long the existence of a column is cached, one minute by default,
+// so columns added by a migration are picked up without restarting.
+type MissingColumns struct {
+	OnMissing func(missing MissingColumn)
+	TTL       time.Duration
+}
+
+// WithMissingColumns configures Apply to drop the conditions on columns that do not exist in the connected database
+// instead of letting the query fail, e.g. during rolling migrations where new instances accept a filter
+// before its column is added. Columns are looked up with the GORM migrator, in the table of the field if it has one
+// and otherwise in the table of the transaction, keeping the conditions when it has neither a model nor a table.
+// Count filters, relation filters and options applied through a view are not checked,
+// and dry run sessions keep every condition.
+// Mandatory conditions, added by claim filters and middleware, and "notin_sub" exclusions like blocklists
+// fail closed: the query fails with ErrMissingColumn instead of running unconstrained.
+// Only conditions are dropped, sorting by or selecting a missing column still fails the query.
+func WithMissingColumns(missing MissingColumns) ParserOption {
+	return func(c *config) {
+		if missing.TTL <= 0 {
+			missing.TTL = time.Minute
+		}
+
+		c.missingColumns = &missingColumns{config: missing, columns: make(map[string]columnEntry)}
+	}
+}
+
+// missingColumns is the configuration of WithMissingColumns with the cached existence of the columns.
+type missingColumns struct {
+	config  MissingColumns
+	mu      sync.Mutex
+	columns map[string]columnEntry
+}
+
+// columnEntry is the cached existence of a column.
+type columnEntry struct {
+	exists  bool
+	expires time.Time
+}
+
+// hasColumn reports whether the given column exists in the given table of the database of the transaction,
+// looking it up with the migrator if it is not cached.
+func (m *missingColumns) hasColumn(tx *gorm.DB, table, column string) bool {
+	key := strings.ToLower(table + "." + column)
+	now := time.Now()
+
+	m.mu.Lock()
+	entry, ok := m.columns[key]
+	m.mu.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		return entry.exists
+	}
+
+	migrator := tx.Session(&gorm.Session{NewDB: true, Context: tx.Statement.Context}).Migrator()
+	entry = columnEntry{exists: migrator.HasColumn(table, column), expires: now.Add(m.config.TTL)}
+
+	m.mu.Lock()
+	m.columns[key] = entry
+	m.mu.Unlock()
+
+	return entry.exists
+}
+
+// present returns the options without the fields on columns missing from the database of the given transaction,
+// notifying the handler of the parser of every dropped field. The options are returned as is
+// if none is missing or the parser does not drop them, see WithMissingColumns.
+// Mandatory fields and "notin_sub" exclusions are never dropped, a missing column fails with ErrMissingColumn instead.
+func (o *Options) present(tx *gorm.DB) (*Options, error) {
+	if o.config == nil || o.config.missingColumns == nil || o.view() != nil || tx.DryRun || len(o.fields) == 0 {
+		return o, nil
+	}
+
+	missing := o.config.missingColumns
+	table, _ := tableName(tx)
+
+	fields := make([]*Field, 0, len(o.fields))
+
+	for _, field := range o.fields {
+		owner := table
+		if field.Table != "" {
+			owner = field.Table
+		}
+
+		_, counted := o.countFilter(field)
+		related := field.Operator == sqlOperatorExists || field.Operator == sqlOperatorNotExists
+
+		if counted || related || owner == "" || missing.hasColumn(tx, owner, field.Name) {
+			fields = append(fields, field)
+			continue
+		}
+
+		if field.mandatory || field.Operator == sqlOperatorNotInSubquery {
+			return nil, fmt.Errorf("%w: %s.%s is required by the %s condition", ErrMissingColumn, owner, field.Name, revertOperator(field.Operator))
+		}
+
+		if missing.config.OnMissing != nil {
+			missing.config.OnMissing(MissingColumn{Table: owner, Column: field.Name, Field: *field})
+		}
+	}
+
+	if len(fields) == len(o.fields) {
+		return o, nil
+	}
+
+	opt := *o
+	opt.fields = fields
+	opt.borrowed = true
+
+	return &opt, nil
+}
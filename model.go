@@ -9,6 +9,14 @@ const (
 	operatorLowerThanEqual   = "lte"
 	operatorLike             = "like"
 	operatorRange            = "rng"
+	operatorHas              = "has"
+	operatorHasNot           = "hasnot"
+	operatorPeriod           = "period"
+	operatorAnyOf            = "anyof"
+	operatorAllOf            = "allof"
+	operatorWords            = "words"
+	operatorInSubquery       = "in_sub"
+	operatorNotInSubquery    = "notin_sub"
 )
 
 const (
@@ -20,16 +28,73 @@ const (
 	sqlOperatorLowerThanEqual   = "<="
 	sqlOperatorLike             = "ILIKE"
 	sqlOperatorRange            = "BETWEEN"
+	sqlOperatorExists           = "EXISTS"
+	sqlOperatorNotExists        = "NOT EXISTS"
+	sqlOperatorPeriod           = "PERIOD"
+	sqlOperatorAnyOf            = "?|"
+	sqlOperatorAllOf            = "?&"
+	sqlOperatorWords            = "WORDS"
+	sqlOperatorInSubquery       = "IN"
+	sqlOperatorNotInSubquery    = "NOT IN"
 )
 
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// Dialect names the SQL flavour used when the options are rendered as plain SQL.
+// The values match the names reported by the GORM dialectors.
+type Dialect string
+
+// Field is a single condition of the options.
+// Values holds the bounds of the ranges of the "range" operator as consecutive pairs,
+// and the elements of the "anyof" and "allof" operators.
+// Encrypted reports whether the values were already encrypted, see WithEncryptedField.
 type Field struct {
-	Name     string
-	Value    string
-	Operator string
+	Name      string
+	Value     string
+	Values    []string
+	Operator  string
+	Class     string
+	Table     string
+	Fold      bool
+	Encrypted bool
+
+	// mandatory reports whether the field was added by the parser rather than requested,
+	// by a claim filter, see WithClaimFilter, or by a middleware, see Parser.Use.
+	// Mandatory fields are not serialized, since deserialized options get them again.
+	mandatory bool
+}
+
+// Sort is a column the results are ordered by.
+type Sort struct {
+	Column string
+	Desc   bool
 }
 
 type Options struct {
-	limit  int
-	offset int
-	fields []*Field
+	limit    int
+	offset   int
+	fields   []*Field
+	sorts    []Sort
+	selects  []string
+	config   *config
+	user     interface{}
+	role     string
+	primary  bool
+	borrowed bool
+	since    *changeCursor
+	after    *keysetCursor
+	unscoped bool
+	includes []include
+	include  string
+}
+
+// condition is a single SQL condition with "?" placeholders and the arguments bound to them.
+// Literal question marks, e.g. of the postgres JSONB operators, are escaped as "??".
+type condition struct {
+	query string
+	args  []interface{}
 }
@@ -0,0 +1,165 @@
+package qparser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm/schema"
+)
+
+var namingSchemas sync.Map
+
+// reservedParameters are the query parameters which are not fields.
+var reservedParameters = map[string]bool{"limit": true, "offset": true, "sort": true, "fields": true, "modified_since": true, "include": true, "after": true}
+
+// modelNaming translates the names clients use for the fields of a model to their columns.
+type modelNaming struct {
+	columns map[string]string
+	err     error
+}
+
+// WithModelNaming configures the parser to accept the parameters of query values, and the columns of the
+// "sort" and "fields" parameters, in the naming styles of the fields of the given model, e.g. a GORM model.
+// Every column of the model is accepted as is, by the name of its Go field, e.g. "CreatedAt",
+// and in camelCase, both of its Go field, e.g. "userID", and of its column, e.g. "userId".
+// Names are resolved to the columns of the model and then to the schema fields with that name or column.
+// Names that do not resolve are rejected as unknown fields, as before.
+func WithModelNaming(model interface{}) ParserOption {
+	return func(c *config) {
+		c.naming = newModelNaming(model)
+	}
+}
+
+// newModelNaming returns the naming of the columns of the given model.
+func newModelNaming(model interface{}) *modelNaming {
+	modelSchema, err := schema.Parse(model, &namingSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return &modelNaming{err: fmt.Errorf("failed to parse model: %w", err)}
+	}
+
+	naming := &modelNaming{columns: make(map[string]string)}
+
+	for _, field := range modelSchema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		for _, name := range []string{field.DBName, field.Name, lowerFirst(field.Name), camelCase(field.DBName)} {
+			if _, ok := naming.columns[name]; !ok {
+				naming.columns[name] = field.DBName
+			}
+		}
+	}
+
+	return naming
+}
+
+// column returns the column of the model the given name resolves to, or the name itself.
+func (n *modelNaming) column(name string) string {
+	if column, ok := n.columns[name]; ok {
+		return column
+	}
+
+	return name
+}
+
+// canonicalName returns the schema field name the given parameter name resolves to with the naming of the parser,
+// or the name itself if it is reserved, already a schema field name, or does not resolve.
+func (c *config) canonicalName(name string) string {
+	if c.naming == nil || c.schema == nil || reservedParameters[name] {
+		return name
+	}
+
+	for _, field := range c.schema.Fields {
+		if field.Name == name {
+			return name
+		}
+	}
+
+	column := c.naming.column(name)
+
+	for _, field := range c.schema.Fields {
+		if field.Name == column || field.column() == column {
+			return field.Name
+		}
+	}
+
+	return name
+}
+
+// canonicalValues returns the given query values with their parameter names and the columns of the
+// "sort" and "fields" parameters resolved with the naming of the parser, see WithModelNaming.
+func (c *config) canonicalValues(values url.Values) (url.Values, error) {
+	if c.naming == nil {
+		return values, nil
+	}
+
+	if c.naming.err != nil {
+		return nil, c.naming.err
+	}
+
+	canonical := make(url.Values, len(values))
+
+	for name, queries := range values {
+		name = c.canonicalName(name)
+
+		switch name {
+		case "sort", "fields":
+			queries = c.canonicalColumns(name, queries)
+		}
+
+		canonical[name] = append(canonical[name], queries...)
+	}
+
+	return canonical, nil
+}
+
+// canonicalColumns resolves the comma-separated columns of the "sort" or "fields" parameter
+// to the columns of the model, keeping the "-" prefix of descending sort columns.
+func (c *config) canonicalColumns(name string, queries []string) []string {
+	resolved := make([]string, 0, len(queries))
+
+	for _, query := range queries {
+		columns := strings.Split(query, ",")
+
+		for i, column := range columns {
+			column = strings.TrimSpace(column)
+
+			if name == "sort" && strings.HasPrefix(column, "-") {
+				columns[i] = "-" + c.naming.column(strings.TrimPrefix(column, "-"))
+				continue
+			}
+
+			columns[i] = c.naming.column(column)
+		}
+
+		resolved = append(resolved, strings.Join(columns, ","))
+	}
+
+	return resolved
+}
+
+// lowerFirst returns the given name with its first letter in lower case, e.g. "userID" for "UserID".
+func lowerFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+// camelCase returns the camelCase form of the given snake_case name, e.g. "userId" for "user_id".
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			r, size := utf8.DecodeRuneInString(parts[i])
+			parts[i] = string(unicode.ToUpper(r)) + parts[i][size:]
+		}
+	}
+
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,60 @@
+package qparser
+
+import "gorm.io/gorm"
+
+// WithoutPagination configures the parser to ignore the limit and offset of the parsed queries,
+// e.g. for internal batch jobs reusing the filters of an endpoint while iterating every row themselves.
+// The limit and offset are still validated, and the default and max limits of the schema are not applied.
+func WithoutPagination() ParserOption {
+	return func(c *config) {
+		c.paginationDisabled = true
+	}
+}
+
+// DisablePagination returns a copy of the options without limit and offset,
+// so Apply, ToSQL and Union do not restrict the rows and callers can iterate them themselves.
+func (o *Options) DisablePagination() *Options {
+	opt := *o
+	opt.limit = 0
+	opt.offset = 0
+	opt.borrowed = true
+
+	return &opt
+}
+
+// paginate applies the limit and offset of the options to the given GORM transaction.
+// Unset values are not applied, so the transaction keeps any pagination set by the caller.
+// The offset is not applied during incremental sync and keyset pagination, which continue after a cursor instead,
+// see NextCursor, and the conditions of the cursor are applied here rather than with the filters,
+// so Count and the other summaries of the filters cover every matching row and not only those following the cursor.
+func (o *Options) paginate(tx *gorm.DB) *gorm.DB {
+	for _, c := range o.cursorConditions(dialectOf(tx)) {
+		tx = tx.Where(c.expression())
+	}
+
+	if o.limit > 0 {
+		tx = tx.Limit(o.limit)
+	}
+
+	if o.offset > 0 && o.since == nil && o.after == nil {
+		tx = tx.Offset(o.offset)
+	}
+
+	return tx
+}
+
+// cursorConditions returns the conditions selecting the rows following the cursor of the incremental sync
+// or of the keyset pagination, if the options have one.
+func (o *Options) cursorConditions(dialect Dialect) []condition {
+	var conditions []condition
+
+	if o.since != nil {
+		conditions = append(conditions, o.sinceCondition())
+	}
+
+	if o.after != nil {
+		conditions = append(conditions, o.afterCondition(dialect))
+	}
+
+	return conditions
+}
@@ -0,0 +1,145 @@
+package qparser
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var defaultParser = NewParser()
+
+// Parser parses filter structs into Options.
+// Its behavior is configured with ParserOption values passed to NewParser,
+// and can be swapped atomically at runtime with Reload and ReloadSchema.
+type Parser struct {
+	config     atomic.Pointer[config]
+	middleware atomic.Pointer[[]Middleware]
+}
+
+// config holds the configuration of a Parser.
+// A config is never modified once it is in use, reloading a parser stores a new one,
+// so Options keep using the configuration they were parsed with.
+type config struct {
+	countFilters       map[string]Relation
+	relations          map[string]Relation
+	policy             Policy
+	scope              ScopeProvider
+	classifications    map[string]ClassificationHook
+	schema             *Schema
+	joins              map[string]string
+	replicaRouting     bool
+	sessionSettings    SessionSettings
+	weekStart          time.Weekday
+	location           *time.Location
+	now                func() time.Time
+	duplicates         DuplicatePolicy
+	redactions         map[string]Redactor
+	defaultRedaction   Redactor
+	auditHooks         []AuditHook
+	costs              map[string]int
+	costPerSecond      float64
+	claims             ClaimsExtractor
+	claimFilters       []ClaimFilter
+	rolePolicies       map[string]FieldPolicy
+	paginationDisabled bool
+	emitter            *AsyncEmitter
+	documentLimits     DocumentLimits
+	encryptions        map[string]Encrypter
+	view               *View
+	changeTracking     ChangeTracking
+	naming             *modelNaming
+	rateLimit          *RateLimit
+	breakdownColumns   []string
+	includes           map[string]*includeConfig
+	leadingWildcards   *leadingWildcards
+	collisions         CollisionHandler
+	tracer             Tracer
+	subqueries         map[string]Subquery
+	missingColumns     *missingColumns
+	conditionLimits    ConditionLimits
+}
+
+// ParserOption configures a Parser.
+type ParserOption func(*config)
+
+// Relation describes a one-to-many relation between the queried table and a related table.
+// Table is the related table, ForeignKey is the column of the related table pointing to the owner,
+// and References is the qualified owner column it points to, e.g. "customers.id".
+type Relation struct {
+	Table      string
+	ForeignKey string
+	References string
+}
+
+// NewParser creates a new Parser configured with the given options.
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{}
+	p.config.Store(newConfig(opts))
+
+	return p
+}
+
+// newConfig creates a new config from the given options.
+func newConfig(opts []ParserOption) *config {
+	cfg := &config{
+		countFilters:    make(map[string]Relation),
+		relations:       make(map[string]Relation),
+		classifications: make(map[string]ClassificationHook),
+		joins:           make(map[string]string),
+		redactions:      make(map[string]Redactor),
+		costs:           make(map[string]int),
+		encryptions:     make(map[string]Encrypter),
+		includes:        make(map[string]*includeConfig),
+		subqueries:      make(map[string]Subquery),
+		weekStart:       time.Monday,
+		location:        time.UTC,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithCountFilter registers a virtual filter counting the related rows of the given relation.
+// A query like "order_count=gte:5" is then compared against the number of related rows
+// instead of a column, e.g. "customers with at least 5 orders".
+func WithCountFilter(name string, relation Relation) ParserOption {
+	return func(c *config) {
+		c.countFilters[name] = relation
+	}
+}
+
+// WithRelation registers a relation usable with the "has" and "hasnot" operators.
+// A query like "relations=has:orders" then only matches rows having at least one related row,
+// and "relations=hasnot:orders" only matches rows without any.
+func WithRelation(name string, relation Relation) ParserOption {
+	return func(c *config) {
+		c.relations[name] = relation
+	}
+}
+
+// WithJoin registers the join clause used for fields owned by the given table,
+// e.g. WithJoin("accounts", "JOIN accounts ON accounts.user_id = users.id").
+// Fields declare their owning table with the "table" tag option, and Apply adds
+// the join of every table used by the options exactly once.
+// Tables without a registered join are only used to qualify the column,
+// which suits the queried table itself or tables joined by the caller.
+func WithJoin(table, join string) ParserOption {
+	return func(c *config) {
+		c.joins[table] = join
+	}
+}
+
+// countQuery returns the correlated subquery counting the related rows of a single owner.
+// A correlated subquery is used instead of a grouped join, so owners without related rows
+// are counted as 0 and still match filters like "lt:1".
+func (r Relation) countQuery() string {
+	return fmt.Sprintf("(SELECT COUNT(*) FROM %s WHERE %s.%s = %s)", r.Table, r.Table, r.ForeignKey, r.References)
+}
+
+// existsQuery returns the correlated subquery selecting the related rows of a single owner.
+func (r Relation) existsQuery() string {
+	return fmt.Sprintf("(SELECT 1 FROM %s WHERE %s.%s = %s)", r.Table, r.Table, r.ForeignKey, r.References)
+}
@@ -0,0 +1,98 @@
+package qparser
+
+import (
+	"fmt"
+	"time"
+)
+
+const periodLayout = "2006-01-02 15:04:05-07:00"
+
+// WithWeekStart configures the first day of the week used by the "period" operator, Monday by default.
+func WithWeekStart(day time.Weekday) ParserOption {
+	return func(c *config) {
+		c.weekStart = day
+	}
+}
+
+// WithLocation configures the time zone the "period" operator computes its periods in, UTC by default.
+func WithLocation(location *time.Location) ParserOption {
+	return func(c *config) {
+		c.location = location
+	}
+}
+
+// periodBounds returns the start and the exclusive end of the given period relative to now.
+// Supported periods are today, yesterday, this_week, last_week, this_month, last_month,
+// this_quarter, last_quarter, this_year and last_year.
+func periodBounds(period string, now time.Time, weekStart time.Weekday) (time.Time, time.Time, error) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	week := today.AddDate(0, 0, -((int(today.Weekday()) - int(weekStart) + 7) % 7))
+	monthStart := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	quarter := time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, now.Location())
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+
+	switch period {
+	case "today":
+		return today, today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case "this_week":
+		return week, week.AddDate(0, 0, 7), nil
+	case "last_week":
+		return week.AddDate(0, 0, -7), week, nil
+	case "this_month":
+		return monthStart, monthStart.AddDate(0, 1, 0), nil
+	case "last_month":
+		return monthStart.AddDate(0, -1, 0), monthStart, nil
+	case "this_quarter":
+		return quarter, quarter.AddDate(0, 3, 0), nil
+	case "last_quarter":
+		return quarter.AddDate(0, -3, 0), quarter, nil
+	case "this_year":
+		return yearStart, yearStart.AddDate(1, 0, 0), nil
+	case "last_year":
+		return yearStart.AddDate(-1, 0, 0), yearStart, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown period %s", period)
+	}
+}
+
+// period returns the start and the exclusive end of the period of the given "period" field,
+// computed when it is called in the time zone of the parser, so options keeping a period
+// like "this_month" always cover the current one, including once serialized and deserialized.
+func (o *Options) period(field *Field) (time.Time, time.Time, error) {
+	now := time.Now
+	weekStart := time.Monday
+	location := time.UTC
+
+	if o.config != nil {
+		weekStart = o.config.weekStart
+
+		if o.config.now != nil {
+			now = o.config.now
+		}
+
+		if o.config.location != nil {
+			location = o.config.location
+		}
+	}
+
+	return periodBounds(field.Value, now().In(location), weekStart)
+}
+
+// periodCondition returns the condition bounding the given column by the current period of the given field,
+// the start inclusive and the end exclusive, see period.
+// The period was validated when the field was added, an unknown period never matches.
+func (o *Options) periodCondition(column string, field *Field) condition {
+	start, end, err := o.period(field)
+	if err != nil {
+		return condition{query: "1 = 0"}
+	}
+
+	return condition{
+		query: fmt.Sprintf("%s %s ? AND %s %s ?", column, sqlOperatorGreaterThanEqual, column, sqlOperatorLowerThan),
+		args:  []interface{}{start.Format(periodLayout), end.Format(periodLayout)},
+	}
+}
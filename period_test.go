@@ -0,0 +1,122 @@
+package qparser
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source for the parser.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) option() ParserOption {
+	return func(cfg *config) {
+		cfg.now = func() time.Time { return c.now }
+	}
+}
+
+// memorySearchStore is a SearchStore keeping the searches in memory.
+type memorySearchStore map[string]*SavedSearch
+
+func (s memorySearchStore) Save(_ context.Context, search *SavedSearch) error {
+	s[search.Owner+"/"+search.Name] = search
+	return nil
+}
+
+func (s memorySearchStore) Get(_ context.Context, owner, name string) (*SavedSearch, error) {
+	search, ok := s[owner+"/"+name]
+	if !ok {
+		return nil, ErrSearchNotFound
+	}
+
+	return search, nil
+}
+
+func (s memorySearchStore) List(context.Context, string) ([]SavedSearch, error) {
+	return nil, nil
+}
+
+func (s memorySearchStore) Delete(context.Context, string, string) error {
+	return nil
+}
+
+func TestPeriodSavedSearchFollowsClock(t *testing.T) {
+	c := &clock{now: time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)}
+	p := NewParser(c.option(), WithSchema(&Schema{
+		Fields: []SchemaField{{Name: "created_at", Operators: []string{"period"}}},
+	}))
+
+	opts, err := p.ParseValues(url.Values{"created_at": {"period:this_month"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	store := memorySearchStore{}
+
+	if _, err := SaveSearch(ctx, store, "alice", "this month", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		now  time.Time
+		args []interface{}
+	}{
+		{
+			now:  time.Date(2026, time.March, 31, 23, 0, 0, 0, time.UTC),
+			args: []interface{}{"2026-03-01 00:00:00+00:00", "2026-04-01 00:00:00+00:00"},
+		},
+		{
+			now:  time.Date(2026, time.April, 2, 8, 0, 0, 0, time.UTC),
+			args: []interface{}{"2026-04-01 00:00:00+00:00", "2026-05-01 00:00:00+00:00"},
+		},
+		{
+			now:  time.Date(2027, time.January, 10, 0, 0, 0, 0, time.UTC),
+			args: []interface{}{"2027-01-01 00:00:00+00:00", "2027-02-01 00:00:00+00:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		c.now = tt.now
+
+		loaded, err := p.LoadSearch(ctx, store, "alice", "this month")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query, args, err := loaded.ToSQL(DialectPostgres)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "WHERE created_at >= ? AND created_at < ?"; query != want {
+			t.Fatalf("got %q, want %q", query, want)
+		}
+
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Fatalf("at %s: got %v, want %v", tt.now, args, tt.args)
+		}
+
+		matched, err := loaded.Match(map[string]interface{}{"created_at": tt.now})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !matched {
+			t.Fatalf("at %s: a row created now does not match the current month", tt.now)
+		}
+	}
+}
+
+func TestPeriodUnknown(t *testing.T) {
+	p := NewParser(WithSchema(&Schema{Fields: []SchemaField{{Name: "created_at"}}}))
+
+	_, err := p.ParseValues(url.Values{"created_at": {"period:next_decade"}})
+	if err == nil || err.Error() != "unknown period next_decade" {
+		t.Fatalf("got %v, want the unknown period rejected", err)
+	}
+}
@@ -0,0 +1,156 @@
+package qparser
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+var playgroundTemplate = template.Must(template.New("playground").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>qparser playground</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+input { width: 100%; font-family: monospace; font-size: 1.1em; padding: .4em; }
+pre { background: #f4f4f4; padding: 1em; white-space: pre-wrap; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>qparser playground</h1>
+<p>Table <code>{{.Table}}</code></p>
+<select id="dialect">
+<option value="postgres">postgres</option>
+<option value="mysql">mysql</option>
+<option value="sqlite">sqlite</option>
+</select>
+<input id="query" placeholder="name=like:bob&amp;age=gte:18&amp;sort=name" autofocus>
+<h2>Error</h2>
+<pre id="error" class="error"></pre>
+<h2>SQL</h2>
+<pre id="sql"></pre>
+<h2>Arguments</h2>
+<pre id="args"></pre>
+<h2>Interpolated</h2>
+<pre id="interpolated"></pre>
+<h2>Parsed options</h2>
+<pre id="options"></pre>
+<script>
+const query = document.getElementById("query");
+const dialect = document.getElementById("dialect");
+let timer;
+
+async function update() {
+	const params = new URLSearchParams({format: "json", q: query.value, dialect: dialect.value});
+	const response = await fetch("?" + params.toString());
+	const result = await response.json();
+
+	document.getElementById("error").textContent = result.error || "";
+	document.getElementById("sql").textContent = result.sql || "";
+	document.getElementById("args").textContent = JSON.stringify(result.args || [], null, 2);
+	document.getElementById("interpolated").textContent = result.interpolated || "";
+	document.getElementById("options").textContent = JSON.stringify(result.options || {}, null, 2);
+}
+
+query.addEventListener("input", () => { clearTimeout(timer); timer = setTimeout(update, 200); });
+dialect.addEventListener("change", update);
+</script>
+</body>
+</html>
+`))
+
+// playgroundResult is the JSON document returned by the playground for a query string.
+type playgroundResult struct {
+	Options      *playgroundOptions `json:"options,omitempty"`
+	SQL          string             `json:"sql,omitempty"`
+	Args         []interface{}      `json:"args,omitempty"`
+	Interpolated string             `json:"interpolated,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// playgroundOptions exposes the parsed options to the playground.
+type playgroundOptions struct {
+	Fields  []*Field `json:"fields"`
+	Sorts   []Sort   `json:"sorts"`
+	Selects []string `json:"selects"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+}
+
+// PlaygroundHandler returns a debug handler rendering a page where developers type query strings
+// and see the parsed options, the generated SQL and the validation errors live.
+// Query strings are parsed with ParseValues of the given parser, so it must have a schema,
+// and the SQL selects from the table of the given model.
+//
+// The playground reveals the filterable fields of the endpoint and must only be mounted in development environments.
+func PlaygroundHandler(parser *Parser, model interface{}) http.Handler {
+	table := ""
+	if s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{}); err == nil {
+		table = s.Table
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") != "json" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			playgroundTemplate.Execute(w, struct{ Table string }{Table: table})
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(playground(parser, table, r.URL.Query()))
+	})
+}
+
+// playground parses the query string of the playground request and renders it for the requested dialect.
+func playground(parser *Parser, table string, params url.Values) playgroundResult {
+	values, err := url.ParseQuery(params.Get("q"))
+	if err != nil {
+		return playgroundResult{Error: err.Error()}
+	}
+
+	options, err := parser.ParseValues(values)
+	if err != nil {
+		return playgroundResult{Error: err.Error()}
+	}
+
+	dialect := Dialect(params.Get("dialect"))
+	if dialect == "" {
+		dialect = DialectPostgres
+	}
+
+	query, args, err := options.ToSQL(dialect)
+	if err != nil {
+		return playgroundResult{Error: err.Error()}
+	}
+
+	interpolated, err := options.InterpolatedSQL(dialect)
+	if err != nil {
+		return playgroundResult{Error: err.Error()}
+	}
+
+	if table != "" {
+		query = strings.TrimSpace("SELECT * FROM " + table + " " + query)
+		interpolated = strings.TrimSpace("SELECT * FROM " + table + " " + interpolated)
+	}
+
+	return playgroundResult{
+		Options: &playgroundOptions{
+			Fields:  options.fields,
+			Sorts:   options.sorts,
+			Selects: options.selects,
+			Limit:   options.limit,
+			Offset:  options.offset,
+		},
+		SQL:          query,
+		Args:         args,
+		Interpolated: interpolated,
+	}
+}
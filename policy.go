@@ -0,0 +1,79 @@
+package qparser
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type userContextKey struct{}
+
+// Policy decides whether a user may filter on a field with an operator.
+// It is consulted for every condition added to Options created by a parser configured with WithPolicy,
+// which allows per-role policies to live in an external engine such as OPA or casbin.
+// The operator is passed in its query form, e.g. "eq" or "like".
+type Policy interface {
+	Allow(user interface{}, field, operator string) bool
+}
+
+// PolicyFunc is an adapter allowing the use of an ordinary function as a Policy.
+type PolicyFunc func(user interface{}, field, operator string) bool
+
+// Allow calls f(user, field, operator).
+func (f PolicyFunc) Allow(user interface{}, field, operator string) bool {
+	return f(user, field, operator)
+}
+
+// ScopeProvider returns the mandatory scope of a user, applied by Options.Apply
+// on top of the parsed conditions, e.g. restricting the rows to the tenant of the user.
+// A nil scope means no restriction.
+type ScopeProvider interface {
+	Scope(user interface{}) func(*gorm.DB) *gorm.DB
+}
+
+// ScopeFunc is an adapter allowing the use of an ordinary function as a ScopeProvider.
+type ScopeFunc func(user interface{}) func(*gorm.DB) *gorm.DB
+
+// Scope calls f(user).
+func (f ScopeFunc) Scope(user interface{}) func(*gorm.DB) *gorm.DB {
+	return f(user)
+}
+
+// ClassificationHook is invoked for every field carrying the classification it is registered for,
+// e.g. a field tagged `query:"ssn,class=pii"`.
+// Returning an error refuses filtering on the field, and modifying the value of the field
+// transforms what is bound to the query, depending on the entitlements of the user.
+type ClassificationHook func(user interface{}, field *Field) error
+
+// WithClassification registers the hook invoked for fields with the given classification.
+// Classified fields without a registered hook are accepted as is.
+func WithClassification(class string, hook ClassificationHook) ParserOption {
+	return func(c *config) {
+		c.classifications[class] = hook
+	}
+}
+
+// WithPolicy configures the parser to authorize every condition with the given policy.
+func WithPolicy(policy Policy) ParserOption {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// WithScope configures the parser to apply the mandatory scope of the user at Apply time.
+func WithScope(provider ScopeProvider) ParserOption {
+	return func(c *config) {
+		c.scope = provider
+	}
+}
+
+// ContextWithUser returns a copy of the context carrying the given user.
+// The user is passed to the Policy and ScopeProvider of the parser by ParseStructContext.
+func ContextWithUser(ctx context.Context, user interface{}) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the user stored in the context by ContextWithUser, or nil.
+func UserFromContext(ctx context.Context) interface{} {
+	return ctx.Value(userContextKey{})
+}
@@ -0,0 +1,59 @@
+package qparser
+
+import "sync"
+
+var (
+	optionsPool = sync.Pool{New: func() interface{} { return &Options{} }}
+	fieldPool   = sync.Pool{New: func() interface{} { return &Field{} }}
+)
+
+// newOptions returns empty options using the given config, reused from the pool when options were released.
+func newOptions(cfg *config) *Options {
+	opt := optionsPool.Get().(*Options)
+	opt.config = cfg
+
+	if opt.fields == nil {
+		opt.fields = make([]*Field, 0)
+	}
+
+	return opt
+}
+
+// newField returns a copy of the given field, reused from the pool when options were released.
+func newField(field Field) *Field {
+	pooled := fieldPool.Get().(*Field)
+	*pooled = field
+
+	return pooled
+}
+
+// releaseField resets the given field and returns it to the pool, it must not be used anymore.
+func releaseField(field *Field) {
+	*field = Field{}
+	fieldPool.Put(field)
+}
+
+// Release returns the options and their fields to a pool, so parsing the next query reuses them
+// instead of allocating, e.g. for gateways parsing tens of thousands of queries per second.
+// Calling Release is optional, options which are not released are garbage collected as usual.
+// The options, their fields and copies made by DisablePagination or Primary must not be used once released,
+// so Release should only be called after the query was applied, and never on options still used
+// in the background, e.g. by a Shadow comparing them. Released options are fully reset before being reused.
+// Releasing a copy made by DisablePagination or Primary does nothing, only the original options are pooled.
+func (o *Options) Release() {
+	if o == nil || o.borrowed {
+		return
+	}
+
+	for i, field := range o.fields {
+		releaseField(field)
+		o.fields[i] = nil
+	}
+
+	for _, include := range o.includes {
+		include.options.Release()
+	}
+
+	*o = Options{fields: o.fields[:0]}
+	optionsPool.Put(o)
+}
@@ -0,0 +1,209 @@
+package qparser
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// poolParser returns a parser accepting the filters used by the pool tests.
+func poolParser(opts ...ParserOption) *Parser {
+	schema := WithSchema(&Schema{
+		Fields: []SchemaField{
+			{Name: "name", Operators: []string{"eq", "like"}},
+			{Name: "age", Operators: []string{"gte", "rng"}},
+			{Name: "created_at", Operators: []string{"period"}},
+			{Name: "nickname"},
+		},
+		Sortable: []string{"name", "age"},
+	})
+
+	return NewParser(append([]ParserOption{schema}, opts...)...)
+}
+
+// poolQuery returns the query values and the SQL they render to for the given goroutine and iteration,
+// distinct for every pair so options reused from the pool by another goroutine are told apart.
+func poolQuery(g, i int) (url.Values, string, []interface{}) {
+	name := fmt.Sprintf("user%d_%d", g, i)
+
+	values := url.Values{
+		"name":  {"like:" + name},
+		"age":   {fmt.Sprintf("rng:%d to %d", g, i)},
+		"sort":  {"-age,name"},
+		"limit": {fmt.Sprint(i%50 + 1)},
+	}
+
+	query := fmt.Sprintf("WHERE name ILIKE ? AND age BETWEEN ? AND ? ORDER BY age DESC, name LIMIT %d", i%50+1)
+
+	return values, query, []interface{}{"%" + name + "%", fmt.Sprint(g), fmt.Sprint(i)}
+}
+
+func TestReleaseReuse(t *testing.T) {
+	p := poolParser()
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				values, want, wantArgs := poolQuery(g, i)
+
+				opts, err := p.ParseValues(values)
+				if err != nil {
+					t.Errorf("ParseValues: %v", err)
+					return
+				}
+
+				query, args, err := opts.ToSQL(DialectPostgres)
+				if err != nil {
+					t.Errorf("ToSQL: %v", err)
+					return
+				}
+
+				if query != want || !reflect.DeepEqual(args, wantArgs) {
+					t.Errorf("got %q %v, want %q %v", query, args, want, wantArgs)
+					return
+				}
+
+				opts.Release()
+			}
+		}(g)
+	}
+
+	wg.Wait()
+}
+
+func TestReleaseBorrowed(t *testing.T) {
+	p := poolParser()
+
+	copies := map[string]func(*Options) *Options{
+		"DisablePagination": (*Options).DisablePagination,
+		"Primary":           (*Options).Primary,
+		"IncludeDeleted":    (*Options).IncludeDeleted,
+	}
+
+	for name, borrow := range copies {
+		t.Run(name, func(t *testing.T) {
+			values, want, wantArgs := poolQuery(0, 0)
+
+			opts, err := p.ParseValues(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					borrowed := borrow(opts)
+					if _, _, err := borrowed.ToSQL(DialectPostgres); err != nil {
+						t.Errorf("ToSQL: %v", err)
+					}
+
+					borrowed.Release()
+
+					// parsing reuses pooled fields, which must not be those of the still used options
+					other, err := p.ParseValues(url.Values{"name": {"eq:other"}})
+					if err != nil {
+						t.Errorf("ParseValues: %v", err)
+						return
+					}
+
+					other.Release()
+				}()
+			}
+
+			wg.Wait()
+
+			query, args, err := opts.ToSQL(DialectPostgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if query != want || !reflect.DeepEqual(args, wantArgs) {
+				t.Fatalf("options changed by the release of a copy: got %q %v, want %q %v", query, args, want, wantArgs)
+			}
+
+			opts.Release()
+		})
+	}
+}
+
+// columnsDialector is a dialector whose migrator reports the given columns as the only existing ones.
+type columnsDialector struct {
+	tests.DummyDialector
+	columns map[string]bool
+}
+
+func (d columnsDialector) Migrator(*gorm.DB) gorm.Migrator {
+	return columnsMigrator{columns: d.columns}
+}
+
+type columnsMigrator struct {
+	gorm.Migrator
+	columns map[string]bool
+}
+
+func (m columnsMigrator) HasColumn(_ interface{}, column string) bool {
+	return m.columns[column]
+}
+
+func TestReleasePresent(t *testing.T) {
+	db, err := gorm.Open(columnsDialector{columns: map[string]bool{"name": true}}, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := poolParser(WithMissingColumns(MissingColumns{}))
+
+	opts, err := p.ParseValues(url.Values{"name": {"eq:alice"}, "nickname": {"eq:al"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			tx := opts.Apply(db.Table("users"))
+			tx.Statement.Build("WHERE")
+
+			if got := tx.Statement.SQL.String(); got != "WHERE name = ?" {
+				t.Errorf("got %q, want the condition on the missing column dropped", got)
+			}
+
+			other, err := p.ParseValues(url.Values{"nickname": {"eq:other"}})
+			if err != nil {
+				t.Errorf("ParseValues: %v", err)
+				return
+			}
+
+			other.Release()
+		}()
+	}
+
+	wg.Wait()
+
+	if len(opts.fields) != 2 || opts.fields[1].Name != "nickname" || opts.fields[1].Value != "al" {
+		t.Fatalf("options changed by dropping missing columns: %+v", opts.fields)
+	}
+
+	opts.Release()
+}
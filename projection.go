@@ -0,0 +1,62 @@
+package qparser
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var projectionSchemas sync.Map
+
+// Project applies the options to the given GORM transaction and returns the rows as maps.
+// Only the selected columns are queried and present in the maps, so a sparse fieldset
+// requested by the client is all that is transferred from the database and serialized.
+// If no columns are selected, every column is returned.
+// The transaction must have a model or table set.
+func (o *Options) Project(tx *gorm.DB) ([]map[string]interface{}, error) {
+	rows := make([]map[string]interface{}, 0)
+
+	if err := o.Apply(tx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// ProjectInto applies the options to the given GORM transaction and scans the rows into dest,
+// which must be a pointer to a slice of partial DTO structs.
+// Only the columns of the DTO are queried, narrowed down to the selected columns if any,
+// so columns the DTO cannot hold are never transferred.
+// The transaction must have a model or table set.
+func (o *Options) ProjectInto(tx *gorm.DB, dest interface{}) error {
+	destType := reflect.TypeOf(dest)
+	if destType == nil || destType.Kind() != reflect.Ptr || destType.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a pointer to a slice")
+	}
+
+	elemType := destType.Elem().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	dto, err := schema.Parse(reflect.New(elemType).Interface(), &projectionSchemas, tx.NamingStrategy)
+	if err != nil {
+		return fmt.Errorf("failed to parse dto: %w", err)
+	}
+
+	columns := make([]string, 0, len(dto.DBNames))
+	for _, column := range dto.DBNames {
+		if len(o.selects) == 0 || contains(o.selects, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	if len(columns) == 0 {
+		return fmt.Errorf("none of the selected fields is part of the dto")
+	}
+
+	return o.Apply(tx).Select(o.viewSelects(columns)).Find(dest).Error
+}
@@ -0,0 +1,358 @@
+package qparsertest
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing/quick"
+)
+
+// ValueFunc generates a random value for a filter, e.g. an integer for a numeric column, see WithValues.
+// Values must not contain ",", " " or " to ", which separate the elements of the "anyof", "allof",
+// "words" and "rng" operators.
+type ValueFunc func(r *rand.Rand) string
+
+// Ints returns a ValueFunc generating integers between min and max, both inclusive.
+func Ints(min, max int) ValueFunc {
+	return func(r *rand.Rand) string {
+		return strconv.Itoa(min + r.Intn(max-min+1))
+	}
+}
+
+// OneOf returns a ValueFunc picking one of the given values, e.g. the states of an enum column.
+func OneOf(values ...string) ValueFunc {
+	return func(r *rand.Rand) string {
+		return values[r.Intn(len(values))]
+	}
+}
+
+// words generates lowercase words of one to eight letters, the default ValueFunc.
+func words(r *rand.Rand) string {
+	word := make([]byte, 1+r.Intn(8))
+	for i := range word {
+		word[i] = byte('a' + r.Intn(26))
+	}
+
+	return string(word)
+}
+
+// defaultOperators are the operators generated by default, those the parser accepts without further configuration.
+var defaultOperators = []string{"eq", "neq", "gt", "gte", "lt", "lte", "like", "rng", "period", "anyof", "allof", "words"}
+
+// periods are the periods accepted by the "period" operator.
+var periods = []string{
+	"today", "yesterday", "this_week", "last_week", "this_month", "last_month",
+	"this_quarter", "last_quarter", "this_year", "last_year",
+}
+
+// GeneratorOption configures a Generator.
+type GeneratorOption func(*Generator)
+
+// WithValues configures the generator to generate the values of the given filter with the given function,
+// lowercase words by default.
+func WithValues(name string, values ValueFunc) GeneratorOption {
+	return func(g *Generator) {
+		g.values[name] = values
+	}
+}
+
+// WithOperators restricts the operators generated for the given filter, e.g. to those its schema allows.
+// The "has", "hasnot", "in_sub" and "notin_sub" operators, which name relations and subqueries
+// registered on the parser, are only generated when listed, with values set by WithValues.
+func WithOperators(name string, operators ...string) GeneratorOption {
+	return func(g *Generator) {
+		g.operators[name] = operators
+	}
+}
+
+// Generator generates random valid and invalid query strings for a filter struct,
+// for property-based tests of the endpoints binding the struct, see NewGenerator.
+type Generator struct {
+	filters   []string
+	flags     []string
+	limit     string
+	offset    string
+	sort      *column
+	fields    *column
+	values    map[string]ValueFunc
+	operators map[string][]string
+}
+
+// column is a "sort" or "fields" parameter of a filter struct, with the columns its "allow" option allows.
+type column struct {
+	name    string
+	allowed []string
+}
+
+// NewGenerator creates a new Generator for the given filter struct, or a pointer to it,
+// from the "query" tags of its fields, see Parser.ParseStruct.
+// String fields, including pointers and custom string types, are filters generated as "operator:value",
+// and boolean fields as "true" or "false". The "limit" and "offset" parameters are generated as integers,
+// and the "sort" and "fields" parameters from the columns of their "allow" option, they are not generated without one.
+// Cursors, includes and fields of other types are not generated.
+func NewGenerator(filter interface{}, opts ...GeneratorOption) (*Generator, error) {
+	t := reflect.TypeOf(filter)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("filter must be a struct or a pointer to a struct")
+	}
+
+	g := &Generator{values: make(map[string]ValueFunc), operators: make(map[string][]string)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("query")
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		var allowed []string
+		for _, option := range parts[1:] {
+			if allow, ok := strings.CutPrefix(option, "allow="); ok {
+				allowed = strings.Split(allow, "|")
+			}
+		}
+
+		kind := field.Type
+		for kind.Kind() == reflect.Ptr {
+			kind = kind.Elem()
+		}
+
+		switch name {
+		case "limit":
+			g.limit = name
+		case "offset":
+			g.offset = name
+		case "sort":
+			g.sort = &column{name: name, allowed: allowed}
+		case "fields":
+			g.fields = &column{name: name, allowed: allowed}
+		case "after", "include", "modified_since":
+		default:
+			switch kind.Kind() {
+			case reflect.String:
+				g.filters = append(g.filters, name)
+			case reflect.Bool:
+				g.flags = append(g.flags, name)
+			}
+		}
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if len(g.filters) == 0 && len(g.flags) == 0 && g.limit == "" && g.offset == "" && g.sort == nil && g.fields == nil {
+		return nil, fmt.Errorf("filter has no fields to generate")
+	}
+
+	for name, operators := range g.operators {
+		if len(operators) == 0 {
+			return nil, fmt.Errorf("filter %s has no operators", name)
+		}
+	}
+
+	return g, nil
+}
+
+// Valid returns random query values the endpoint must accept, e.g. for use with rapid:
+//
+//	values := rapid.Custom(func(t *rapid.T) url.Values {
+//		return generator.Valid(rand.New(rand.NewSource(rapid.Int64().Draw(t, "seed"))))
+//	})
+//
+// Every filter and parameter is set with a probability of one half.
+func (g *Generator) Valid(r *rand.Rand) url.Values {
+	values := url.Values{}
+
+	for _, name := range g.filters {
+		if r.Intn(2) == 0 {
+			values.Set(name, g.filter(r, name))
+		}
+	}
+
+	for _, name := range g.flags {
+		if r.Intn(2) == 0 {
+			values.Set(name, strconv.FormatBool(r.Intn(2) == 0))
+		}
+	}
+
+	if g.limit != "" && r.Intn(2) == 0 {
+		values.Set(g.limit, strconv.Itoa(1+r.Intn(100)))
+	}
+
+	if g.offset != "" && r.Intn(2) == 0 {
+		values.Set(g.offset, strconv.Itoa(r.Intn(1000)))
+	}
+
+	if g.sort != nil && len(g.sort.allowed) > 0 && r.Intn(2) == 0 {
+		columns := subset(r, g.sort.allowed)
+		for i, column := range columns {
+			if r.Intn(2) == 0 {
+				columns[i] = "-" + column
+			}
+		}
+
+		values.Set(g.sort.name, strings.Join(columns, ","))
+	}
+
+	if g.fields != nil && len(g.fields.allowed) > 0 && r.Intn(2) == 0 {
+		values.Set(g.fields.name, strings.Join(subset(r, g.fields.allowed), ","))
+	}
+
+	return values
+}
+
+// Invalid returns random query values the endpoint must reject: valid values with one of them corrupted,
+// e.g. a filter without operator, an unknown operator, a malformed range or a negative limit.
+// Boolean filters are corrupted into values like "maybe", which the binding of the struct rejects
+// before the options are parsed.
+func (g *Generator) Invalid(r *rand.Rand) url.Values {
+	values := g.Valid(r)
+
+	corruptions := make([]func(), 0, 4)
+
+	if len(g.filters) > 0 {
+		corruptions = append(corruptions, func() {
+			name := g.filters[r.Intn(len(g.filters))]
+			value := g.value(r, name)
+
+			invalid := []string{
+				value,
+				OneOf("equals", "contains", "in", "EQ", "not")(r) + ":" + value,
+				"rng:" + value,
+				"rng:" + value + " to " + value + "|" + value,
+				"period:" + value + "_ago",
+				"anyof:,",
+				"allof: , ",
+				"words: ",
+			}
+
+			values.Set(name, invalid[r.Intn(len(invalid))])
+		})
+	}
+
+	if len(g.flags) > 0 {
+		corruptions = append(corruptions, func() {
+			values.Set(g.flags[r.Intn(len(g.flags))], OneOf("maybe", "yes!", "2")(r))
+		})
+	}
+
+	for _, name := range []string{g.limit, g.offset} {
+		if name != "" {
+			name := name
+
+			corruptions = append(corruptions, func() {
+				values.Set(name, OneOf("-1", "-100", "many", "1.5")(r))
+			})
+		}
+	}
+
+	for _, c := range []*column{g.sort, g.fields} {
+		if c != nil {
+			c := c
+
+			corruptions = append(corruptions, func() {
+				invalid := []string{"1column", "name;drop", "na me", "column()"}
+
+				if len(c.allowed) > 0 {
+					invalid = append(invalid, c.allowed[0]+"_not_allowed")
+				}
+
+				values.Set(c.name, invalid[r.Intn(len(invalid))])
+			})
+		}
+	}
+
+	if len(corruptions) > 0 {
+		corruptions[r.Intn(len(corruptions))]()
+	}
+
+	return values
+}
+
+// QuickValid returns a testing/quick configuration generating the arguments of the checked function with Valid,
+// the arguments must be url.Values:
+//
+//	err := quick.Check(func(values url.Values) bool {
+//		return get("/users?"+values.Encode()).StatusCode == http.StatusOK
+//	}, generator.QuickValid())
+func (g *Generator) QuickValid() *quick.Config {
+	return &quick.Config{Values: quickValues(g.Valid)}
+}
+
+// QuickInvalid returns a testing/quick configuration generating the arguments of the checked function with Invalid,
+// see QuickValid.
+func (g *Generator) QuickInvalid() *quick.Config {
+	return &quick.Config{Values: quickValues(g.Invalid)}
+}
+
+// quickValues returns the testing/quick function filling the arguments of the checked function
+// with query values generated by the given function.
+func quickValues(generate func(r *rand.Rand) url.Values) func(args []reflect.Value, r *rand.Rand) {
+	return func(args []reflect.Value, r *rand.Rand) {
+		for i := range args {
+			args[i] = reflect.ValueOf(generate(r))
+		}
+	}
+}
+
+// filter returns a random valid "operator:value" query of the given filter.
+func (g *Generator) filter(r *rand.Rand, name string) string {
+	operators, ok := g.operators[name]
+	if !ok {
+		operators = defaultOperators
+	}
+
+	operator := operators[r.Intn(len(operators))]
+
+	switch operator {
+	case "rng":
+		return fmt.Sprintf("rng:%s to %s", g.value(r, name), g.value(r, name))
+	case "period":
+		return "period:" + OneOf(periods...)(r)
+	case "anyof", "allof", "words":
+		separator := ","
+		if operator == "words" {
+			separator = " "
+		}
+
+		elements := make([]string, 1+r.Intn(3))
+		for i := range elements {
+			elements[i] = g.value(r, name)
+		}
+
+		return operator + ":" + strings.Join(elements, separator)
+	default:
+		return operator + ":" + g.value(r, name)
+	}
+}
+
+// value returns a random value of the given filter.
+func (g *Generator) value(r *rand.Rand, name string) string {
+	if values, ok := g.values[name]; ok {
+		return values(r)
+	}
+
+	return words(r)
+}
+
+// subset returns a random non-empty subset of the given columns, in random order.
+func subset(r *rand.Rand, columns []string) []string {
+	shuffled := make([]string, len(columns))
+	for i, j := range r.Perm(len(columns)) {
+		shuffled[i] = columns[j]
+	}
+
+	return shuffled[:1+r.Intn(len(shuffled))]
+}
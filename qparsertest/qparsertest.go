@@ -0,0 +1,72 @@
+// Package qparsertest provides helpers for testing code built on qparser.
+//
+// AssertReadOnly lets security reviews and downstream tests rely on qparser being read-only by construction:
+//
+//	func TestUsersQueryIsReadOnly(t *testing.T) {
+//		options, err := parser.ParseValues(url.Values{"name": {"like:john"}})
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//
+//		qparsertest.AssertReadOnly(t, db, options, &User{})
+//	}
+//
+// A Generator produces random valid and invalid query strings for a filter struct,
+// to property-test how endpoints handle every operator combination with testing/quick or rapid.
+package qparsertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/0x16F/qparser"
+	"gorm.io/gorm"
+)
+
+// CheckReadOnly verifies that applying the options to a session of the given database has no side effects.
+// The options are applied in a dry run session on the given model, so no statement reaches the database,
+// and CheckReadOnly returns an error if Apply executed a statement itself, if the built statement
+// is not a single SELECT, or if it locks the selected rows.
+func CheckReadOnly(db *gorm.DB, options *qparser.Options, model interface{}) error {
+	session := db.Session(&gorm.Session{DryRun: true, NewDB: true}).Model(model)
+
+	applied := options.Apply(session)
+	if applied.Error != nil {
+		return fmt.Errorf("failed to apply options: %w", applied.Error)
+	}
+
+	if applied.Statement.SQL.Len() > 0 {
+		return fmt.Errorf("apply executed %q", applied.Statement.SQL.String())
+	}
+
+	stmt := applied.Find(&[]map[string]interface{}{}).Statement
+	if stmt.Error != nil {
+		return fmt.Errorf("failed to build query: %w", stmt.Error)
+	}
+
+	query := strings.TrimSpace(stmt.SQL.String())
+
+	if !strings.HasPrefix(strings.ToUpper(query), "SELECT ") {
+		return fmt.Errorf("query %q is not a SELECT", query)
+	}
+
+	if strings.Contains(query, ";") {
+		return fmt.Errorf("query %q contains several statements", query)
+	}
+
+	if _, ok := stmt.Clauses["FOR"]; ok {
+		return fmt.Errorf("query %q locks rows", query)
+	}
+
+	return nil
+}
+
+// AssertReadOnly fails the test if applying the options to the given model has side effects, see CheckReadOnly.
+func AssertReadOnly(t testing.TB, db *gorm.DB, options *qparser.Options, model interface{}) {
+	t.Helper()
+
+	if err := CheckReadOnly(db, options, model); err != nil {
+		t.Fatalf("options are not read-only: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package qparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a caller repeats an identical query more often than allowed, see WithRateLimit.
+var ErrRateLimited = errors.New("identical query repeated too often, retry later")
+
+// Limiter decides whether another identical query is allowed for the given key,
+// which identifies the caller and the query. When it is not, Allow returns how long to wait before retrying.
+type Limiter interface {
+	Allow(key string) (bool, time.Duration)
+}
+
+// RateLimit configures the limiting of identical queries per caller, see WithRateLimit.
+// Principal returns the caller of the parse context, and defaults to the user stored by ContextWithUser.
+// Only queries costing at least MinCost are limited, see Options.Cost, so cheap queries are never throttled.
+// Rejected queries fail with ErrRateLimited, unless Delay is set, in which case parsing waits until
+// the query is allowed, failing only if the context is done or its deadline is too close to wait.
+type RateLimit struct {
+	Limiter   Limiter
+	Principal func(ctx context.Context) string
+	MinCost   int
+	Delay     bool
+}
+
+// WithRateLimit configures the parser to limit how often a caller may repeat an identical query,
+// identified by Options.Hash, e.g. to stop dashboard auto-refresh storms from hammering the database.
+// Queries of contexts without a principal are not limited.
+func WithRateLimit(limit RateLimit) ParserOption {
+	return func(c *config) {
+		c.rateLimit = &limit
+	}
+}
+
+// BucketLimiter is a Limiter allowing a fixed number of identical queries per key in every time bucket.
+// It is safe for concurrent use.
+type BucketLimiter struct {
+	limit   int
+	window  time.Duration
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweep   time.Time
+}
+
+type bucket struct {
+	start time.Time
+	count int
+}
+
+// NewBucketLimiter creates a new BucketLimiter allowing limit identical queries per key in every window.
+func NewBucketLimiter(limit int, window time.Duration) *BucketLimiter {
+	return &BucketLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: make(map[string]*bucket),
+		sweep:   time.Now(),
+	}
+}
+
+// Allow counts a query for the given key in the current bucket, and reports whether it is within the limit.
+// Otherwise it returns how long until the bucket of the key ends.
+func (l *BucketLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.sweep) >= l.window {
+		for k, b := range l.buckets {
+			if now.Sub(b.start) >= l.window {
+				delete(l.buckets, k)
+			}
+		}
+
+		l.sweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok || now.Sub(b.start) >= l.window {
+		b = &bucket{start: now}
+		l.buckets[key] = b
+	}
+
+	if b.count >= l.limit {
+		return false, b.start.Add(l.window).Sub(now)
+	}
+
+	b.count++
+
+	return true, 0
+}
+
+// checkRate returns ErrRateLimited if the caller of the given context repeats the options too often,
+// or waits until they are allowed if the rate limit of the parser delays queries.
+func (o *Options) checkRate(ctx context.Context) error {
+	if o.config == nil || o.config.rateLimit == nil {
+		return nil
+	}
+
+	limit := o.config.rateLimit
+
+	principal := ""
+	if limit.Principal != nil {
+		principal = limit.Principal(ctx)
+	} else {
+		principal = o.principal()
+	}
+
+	if principal == "" || o.Cost() < limit.MinCost {
+		return nil
+	}
+
+	key := principal + ":" + o.Hash()
+
+	for {
+		allowed, retryAfter := limit.Limiter.Allow(key)
+		if allowed {
+			return nil
+		}
+
+		if !limit.Delay {
+			return fmt.Errorf("%w: retry after %s", ErrRateLimited, retryAfter.Round(time.Millisecond))
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < retryAfter {
+			return fmt.Errorf("%w: retry after %s", ErrRateLimited, retryAfter.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(retryAfter)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
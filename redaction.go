@@ -0,0 +1,132 @@
+package qparser
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Redactor transforms the value of a field before it is logged or audited,
+// so values of sensitive fields do not leak while their names and operators remain visible.
+type Redactor func(value string) string
+
+// AuditEntry describes parsed options for audit logs.
+// The values of the fields are redacted according to the redaction policy of the parser.
+type AuditEntry struct {
+	User   interface{}
+	Fields []Field
+}
+
+// AuditHook is notified with every option successfully parsed by ParseStructContext or ParseValuesContext.
+type AuditHook func(ctx context.Context, entry AuditEntry)
+
+// MaskValue is a Redactor replacing every value by "***".
+func MaskValue(string) string {
+	return "***"
+}
+
+// HashRedactor returns a Redactor replacing every value by its HMAC-SHA256 under the given key,
+// so equal values can still be correlated across log lines without revealing them.
+// A keyed hash is used since plain hashes of low-entropy values like phone numbers are easily reversed.
+func HashRedactor(key []byte) Redactor {
+	return func(value string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+}
+
+// WithRedaction configures the redactor applied to the values of fields with the given classification.
+func WithRedaction(class string, redactor Redactor) ParserOption {
+	return func(c *config) {
+		c.redactions[class] = redactor
+	}
+}
+
+// WithDefaultRedaction configures the redactor applied to the values of fields
+// without a redactor registered for their classification, including unclassified fields.
+func WithDefaultRedaction(redactor Redactor) ParserOption {
+	return func(c *config) {
+		c.defaultRedaction = redactor
+	}
+}
+
+// WithAuditHook registers a hook notified with the redacted fields of every parsed option.
+func WithAuditHook(hook AuditHook) ParserOption {
+	return func(c *config) {
+		c.auditHooks = append(c.auditHooks, hook)
+	}
+}
+
+// Redacted returns copies of the fields of the options with their values redacted
+// according to the redaction policy of the parser, for logging.
+// Fields without a matching redactor keep their values.
+func (o *Options) Redacted() []Field {
+	fields := make([]Field, 0, len(o.fields))
+
+	for _, field := range o.fields {
+		redacted := *field
+
+		if redactor := o.redactor(field); redactor != nil {
+			redacted.Value = redactor(field.Value)
+
+			if field.Values != nil {
+				redacted.Values = make([]string, 0, len(field.Values))
+				for _, value := range field.Values {
+					redacted.Values = append(redacted.Values, redactor(value))
+				}
+			}
+		}
+
+		fields = append(fields, redacted)
+	}
+
+	return fields
+}
+
+// String renders the redacted fields of the options for logs, e.g. `age gte "18" AND ssn eq "***"`.
+func (o *Options) String() string {
+	conditions := make([]string, 0, len(o.fields))
+
+	for _, field := range o.Redacted() {
+		conditions = append(conditions, fmt.Sprintf("%s %s %q", field.Name, revertOperator(field.Operator), field.Value))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+// LogValue implements slog.LogValuer, so options passed to structured loggers are logged redacted.
+func (o *Options) LogValue() slog.Value {
+	return slog.StringValue(o.String())
+}
+
+// redactor returns the redactor applied to the value of the given field, or nil.
+func (o *Options) redactor(field *Field) Redactor {
+	if o.config == nil {
+		return nil
+	}
+
+	if redactor, ok := o.config.redactions[field.Class]; ok && field.Class != "" {
+		return redactor
+	}
+
+	return o.config.defaultRedaction
+}
+
+// audit notifies the audit hooks of the parser with the redacted fields of the options.
+func (o *Options) audit(ctx context.Context) {
+	if o.config == nil || len(o.config.auditHooks) == 0 {
+		return
+	}
+
+	entry := AuditEntry{User: o.user, Fields: o.Redacted()}
+
+	for _, hook := range o.config.auditHooks {
+		hook(ctx, entry)
+	}
+}
@@ -0,0 +1,138 @@
+package qparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RejectionReason names why a filter was rejected, see RejectionError.
+type RejectionReason string
+
+const (
+	ReasonUnknownField       RejectionReason = "unknown_field"
+	ReasonUnknownOperator    RejectionReason = "unknown_operator"
+	ReasonOperatorNotAllowed RejectionReason = "operator_not_allowed"
+	ReasonSortNotAllowed     RejectionReason = "sort_not_allowed"
+	ReasonSelectNotAllowed   RejectionReason = "select_not_allowed"
+	ReasonIncludeNotAllowed  RejectionReason = "include_not_allowed"
+	ReasonTooManyBranches    RejectionReason = "too_many_branches"
+	ReasonTooDeep            RejectionReason = "too_deep"
+	ReasonTooManyConditions  RejectionReason = "too_many_conditions"
+)
+
+// queryOperators are the operators in query form, suggested for unknown operators.
+var queryOperators = []string{
+	operatorEqual, operatorNotEqual, operatorGreaterThan, operatorGreaterThanEqual, operatorLowerThan,
+	operatorLowerThanEqual, operatorLike, operatorRange, operatorHas, operatorHasNot, operatorPeriod,
+	operatorAnyOf, operatorAllOf, operatorWords, operatorInSubquery, operatorNotInSubquery,
+}
+
+// RejectionError describes a filter rejected by the parser, e.g. to return it as a structured API error.
+// Name is the rejected field, operator or column, and Field the field it was used on, if any.
+// Suggestions are the allowed names closest to the rejected one by edit distance, the closest first,
+// or every allowed operator of the field when the operator is not allowed for it,
+// so clients can be told what they probably meant.
+type RejectionError struct {
+	Reason      RejectionReason `json:"reason"`
+	Name        string          `json:"name"`
+	Field       string          `json:"field,omitempty"`
+	Suggestions []string        `json:"suggestions,omitempty"`
+	message     string
+}
+
+// Error returns the message of the rejection, followed by its suggestions.
+func (e *RejectionError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return e.message
+	}
+
+	return fmt.Sprintf("%s, did you mean %s?", e.message, strings.Join(e.Suggestions, " or "))
+}
+
+// reject returns a RejectionError with the given message, suggesting the candidates closest to the rejected name.
+func reject(reason RejectionReason, name, field string, candidates []string, message string) *RejectionError {
+	return &RejectionError{
+		Reason:      reason,
+		Name:        name,
+		Field:       field,
+		Suggestions: suggest(name, candidates),
+		message:     message,
+	}
+}
+
+// suggest returns up to three of the candidates close enough to the given name to be a typo of it,
+// ordered by edit distance and then alphabetically.
+// A candidate is close enough when at most a third of the name, and at least one character, must be edited.
+func suggest(name string, candidates []string) []string {
+	maxDistance := len(name) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	distances := make(map[string]int)
+	suggestions := make([]string, 0)
+
+	for _, candidate := range candidates {
+		if _, ok := distances[candidate]; ok || candidate == name {
+			continue
+		}
+
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if distance > maxDistance {
+			continue
+		}
+
+		distances[candidate] = distance
+		suggestions = append(suggestions, candidate)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if distances[suggestions[i]] != distances[suggestions[j]] {
+			return distances[suggestions[i]] < distances[suggestions[j]]
+		}
+
+		return suggestions[i] < suggestions[j]
+	})
+
+	if len(suggestions) > 3 {
+		suggestions = suggestions[:3]
+	}
+
+	return suggestions
+}
+
+// levenshtein returns the edit distance between the given strings, the number of single character insertions,
+// deletions, substitutions and transpositions of adjacent characters turning one into the other.
+func levenshtein(a, b string) int {
+	x, y := []rune(a), []rune(b)
+
+	beforePrevious := make([]int, len(y)+1)
+	previous := make([]int, len(y)+1)
+	current := make([]int, len(y)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(x); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+
+			if i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] {
+				current[j] = min(current[j], beforePrevious[j-2]+1)
+			}
+		}
+
+		beforePrevious, previous, current = previous, current, beforePrevious
+	}
+
+	return previous[len(y)]
+}
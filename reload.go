@@ -0,0 +1,77 @@
+package qparser
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Reload atomically replaces the whole configuration of the parser with one built from the given options.
+// Options parsed before the reload keep using the previous configuration.
+func (p *Parser) Reload(opts ...ParserOption) {
+	p.config.Store(newConfig(opts))
+}
+
+// ReloadSchema atomically replaces the schema of the parser, keeping the rest of its configuration.
+// Options parsed before the reload keep using the previous schema.
+func (p *Parser) ReloadSchema(schema *Schema) {
+	for {
+		current := p.config.Load()
+
+		next := *current
+		next.schema = schema
+
+		if p.config.CompareAndSwap(current, &next) {
+			return
+		}
+	}
+}
+
+// WatchSchemaFile polls the schema file at the given path every interval
+// and reloads the schema of the parser whenever the modification time of the file changes.
+// Errors while reading or validating the file are passed to onError, if set,
+// and the parser keeps its current schema until the file is fixed.
+// It blocks until the context is done, so it is usually run in its own goroutine.
+func (p *Parser) WatchSchemaFile(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	var modTime time.Time
+
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+
+			continue
+		}
+
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+
+		schema, err := LoadSchemaFile(path)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+
+			continue
+		}
+
+		modTime = info.ModTime()
+		p.ReloadSchema(schema)
+	}
+}
@@ -0,0 +1,20 @@
+package qparser
+
+// WithReplicaRouting configures the parser to mark the queries built by Apply for replica reads.
+// Queries built by qparser only ever read, so they are safe to serve from replicas configured
+// with the GORM dbresolver plugin. Use Options.Primary to read from the primary database instead.
+func WithReplicaRouting() ParserOption {
+	return func(c *config) {
+		c.replicaRouting = true
+	}
+}
+
+// Primary returns a copy of the options reading from the primary database,
+// for calls that must observe their own writes even though the parser routes to replicas.
+func (o *Options) Primary() *Options {
+	opt := *o
+	opt.primary = true
+	opt.borrowed = true
+
+	return &opt
+}
@@ -0,0 +1,166 @@
+package qparser
+
+import (
+	"context"
+	"fmt"
+)
+
+type roleContextKey struct{}
+
+// FieldPolicy restricts what a role may filter, sort and select on top of the allowlists of the endpoint.
+// Fields maps the fields the role may filter on to their allowed operators in query form,
+// a field without operators allows every operator.
+// Sortable and Selectable list the columns the role may sort by and select.
+// A nil map or list does not restrict the role, e.g. FieldPolicy{} for administrators.
+type FieldPolicy struct {
+	Fields     map[string][]string
+	Sortable   []string
+	Selectable []string
+}
+
+// WithRolePolicy configures the field policies of the roles, chosen per request by the role
+// stored in the parse context by ContextWithRole, e.g. so administrators can filter on internal columns
+// that are forbidden for public API consumers.
+// Parsing without a role or with a role missing from the policies results in an error.
+func WithRolePolicy(policies map[string]FieldPolicy) ParserOption {
+	return func(c *config) {
+		c.rolePolicies = policies
+	}
+}
+
+// ContextWithRole returns a copy of the context carrying the given role, see WithRolePolicy.
+func ContextWithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleContextKey{}, role)
+}
+
+// RoleFromContext returns the role stored in the context by ContextWithRole, or an empty string.
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleContextKey{}).(string)
+
+	return role
+}
+
+// fieldPolicy returns the field policy of the role of the options, or nil if the parser has no role policies.
+func (o *Options) fieldPolicy() (*FieldPolicy, error) {
+	if o.config == nil {
+		return nil, nil
+	}
+
+	return o.config.fieldPolicy(o.role)
+}
+
+// fieldPolicy returns the field policy of the given role, or nil if the parser has no role policies.
+func (c *config) fieldPolicy(role string) (*FieldPolicy, error) {
+	if c.rolePolicies == nil {
+		return nil, nil
+	}
+
+	policy, ok := c.rolePolicies[role]
+	if !ok {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+
+	return &policy, nil
+}
+
+// allowsField reports whether the policy allows filtering on the given field, a nil policy allows every field.
+func (p *FieldPolicy) allowsField(name string) bool {
+	if p == nil || p.Fields == nil {
+		return true
+	}
+
+	_, ok := p.Fields[name]
+
+	return ok
+}
+
+// sortable returns the given sortable columns restricted to those the role of the options may sort by,
+// so rejections never suggest columns hidden from the caller. Nil columns accept every column.
+func (o *Options) sortable(columns []string) ([]string, error) {
+	policy, err := o.fieldPolicy()
+	if err != nil || policy == nil {
+		return columns, err
+	}
+
+	return visibleColumns(columns, policy.Sortable), nil
+}
+
+// selectable returns the given selectable columns restricted to those the role of the options may select,
+// so rejections never suggest columns hidden from the caller. Nil columns accept every column.
+func (o *Options) selectable(columns []string) ([]string, error) {
+	policy, err := o.fieldPolicy()
+	if err != nil || policy == nil {
+		return columns, err
+	}
+
+	return visibleColumns(columns, policy.Selectable), nil
+}
+
+// visibleColumns returns the given columns also part of the allowed ones.
+// Nil columns or allowed columns do not restrict the result.
+func visibleColumns(columns, allowed []string) []string {
+	if allowed == nil {
+		return columns
+	}
+
+	if columns == nil {
+		return allowed
+	}
+
+	visible := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		if contains(allowed, column) {
+			visible = append(visible, column)
+		}
+	}
+
+	return visible
+}
+
+// authorizeField checks that the role of the options may filter on the given field with its operator.
+func (o *Options) authorizeField(field *Field) error {
+	policy, err := o.fieldPolicy()
+	if err != nil || policy == nil || policy.Fields == nil {
+		return err
+	}
+
+	operators, ok := policy.Fields[field.Name]
+	if !ok {
+		return fmt.Errorf("filtering on %s is not allowed", field.Name)
+	}
+
+	operator := revertOperator(field.Operator)
+
+	if len(operators) > 0 && !contains(operators, operator) {
+		return fmt.Errorf("filtering on %s with operator %s is not allowed", field.Name, operator)
+	}
+
+	return nil
+}
+
+// authorizeColumns checks that the role of the options may sort by and select the columns of the options.
+func (o *Options) authorizeColumns() error {
+	policy, err := o.fieldPolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+
+	if policy.Sortable != nil {
+		for _, sort := range o.sorts {
+			if !contains(policy.Sortable, sort.Column) {
+				return fmt.Errorf("sorting by %s is not allowed", sort.Column)
+			}
+		}
+	}
+
+	if policy.Selectable != nil {
+		for _, column := range o.selects {
+			if !contains(policy.Selectable, column) {
+				return fmt.Errorf("selecting %s is not allowed", column)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package qparser
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roleParser returns a parser hiding the salary of the employees from the public role.
+func roleParser() *Parser {
+	return NewParser(
+		WithSchema(&Schema{
+			Fields: []SchemaField{
+				{Name: "name", Operators: []string{"eq", "like"}},
+				{Name: "salary"},
+				{Name: "sales"},
+			},
+			Sortable:   []string{"name", "salary", "sales"},
+			Selectable: []string{"name", "salary", "sales"},
+		}),
+		WithRolePolicy(map[string]FieldPolicy{
+			"admin": {},
+			"public": {
+				Fields:     map[string][]string{"name": {"eq"}, "sales": nil},
+				Sortable:   []string{"name", "sales"},
+				Selectable: []string{"name", "sales"},
+			},
+		}),
+	)
+}
+
+func TestRoleSuggestions(t *testing.T) {
+	p := roleParser()
+
+	tests := []struct {
+		role        string
+		query       string
+		suggestions []string
+	}{
+		{role: "admin", query: "salry=eq:1", suggestions: []string{"salary"}},
+		{role: "public", query: "salry=eq:1", suggestions: []string{}},
+		{role: "public", query: "sale=eq:1", suggestions: []string{"sales"}},
+		{role: "admin", query: "sort=salry", suggestions: []string{"salary"}},
+		{role: "public", query: "sort=salry", suggestions: []string{}},
+		{role: "admin", query: "fields=salry", suggestions: []string{"salary"}},
+		{role: "public", query: "fields=salry", suggestions: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = p.ParseValuesContext(ContextWithRole(context.Background(), tt.role), values)
+
+			var rejection *RejectionError
+			if !errors.As(err, &rejection) {
+				t.Fatalf("got %v, want a rejection", err)
+			}
+
+			if !reflect.DeepEqual(rejection.Suggestions, tt.suggestions) {
+				t.Fatalf("got suggestions %v, want %v", rejection.Suggestions, tt.suggestions)
+			}
+		})
+	}
+}
+
+func TestRoleSuggestionsOfDocuments(t *testing.T) {
+	p := roleParser()
+	ctx := ContextWithRole(context.Background(), "public")
+
+	_, err := p.ParseJSONContext(ctx, strings.NewReader(`{"salry": "eq:1"}`))
+
+	var rejection *RejectionError
+	if !errors.As(err, &rejection) || len(rejection.Suggestions) != 0 {
+		t.Fatalf("got %v, want no suggestion", err)
+	}
+
+	_, err = p.UnmarshalOptionsContext(ctx, []byte(`{"version":1,"fields":[{"name":"salry","operator":"eq","value":"1"}]}`))
+	if !errors.As(err, &rejection) || len(rejection.Suggestions) != 0 {
+		t.Fatalf("got %v, want no suggestion", err)
+	}
+}
+
+func TestRoleFieldPolicies(t *testing.T) {
+	p := roleParser()
+
+	tests := []struct {
+		role  string
+		query string
+		want  string
+		err   string
+	}{
+		{role: "admin", query: "salary=gt:100&sort=salary&fields=name,salary", want: "WHERE salary > ? ORDER BY salary"},
+		{role: "public", query: "name=eq:bob&sales=gt:1&sort=-sales&fields=name", want: "WHERE name = ? AND sales > ? ORDER BY sales DESC"},
+		{role: "public", query: "salary=gt:100", err: "filtering on salary is not allowed"},
+		{role: "public", query: "name=like:bob", err: "filtering on name with operator like is not allowed"},
+		{role: "public", query: "sort=salary", err: "sorting by salary is not allowed"},
+		{role: "public", query: "fields=name,salary", err: "selecting salary is not allowed"},
+		{role: "", query: "name=eq:bob", err: `unknown role ""`},
+		{role: "guest", query: "name=eq:bob", err: `unknown role "guest"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts, err := p.ParseValuesContext(ContextWithRole(context.Background(), tt.role), values)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v, want %s", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query, _, err := opts.ToSQL(DialectPostgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if query != tt.want {
+				t.Fatalf("got %q, want %q", query, tt.want)
+			}
+		})
+	}
+}
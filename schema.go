@@ -0,0 +1,357 @@
+package qparser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema declares the filterable fields of an endpoint as an alternative to struct tags.
+// It is usually loaded from a YAML or JSON document with LoadSchema at startup,
+// so the filterability of an endpoint can be managed without code changes.
+// Sortable lists the columns accepted by the "sort" parameter, sorting is rejected when it is empty.
+// Selectable lists the columns accepted by the "fields" parameter, selecting is rejected when it is empty.
+type Schema struct {
+	Fields       []SchemaField `json:"fields" yaml:"fields"`
+	Sortable     []string      `json:"sortable" yaml:"sortable"`
+	Selectable   []string      `json:"selectable" yaml:"selectable"`
+	DefaultLimit int           `json:"default_limit" yaml:"default_limit"`
+	MaxLimit     int           `json:"max_limit" yaml:"max_limit"`
+}
+
+// SchemaField declares a single filterable field.
+// Name is the query parameter, Column is the filtered column and defaults to Name.
+// Operators lists the allowed operators in their query form, all operators are allowed when it is empty.
+// Default is the query used when the parameter is missing, e.g. "eq:active".
+// Class classifies the field like the "class" tag option does,
+// Table declares its owning table like the "table" tag option does,
+// and Fold enables case folding for the "like" operator like the "fold" tag option does.
+type SchemaField struct {
+	Name      string   `json:"name" yaml:"name"`
+	Column    string   `json:"column" yaml:"column"`
+	Operators []string `json:"operators" yaml:"operators"`
+	Default   string   `json:"default" yaml:"default"`
+	Class     string   `json:"class" yaml:"class"`
+	Table     string   `json:"table" yaml:"table"`
+	Fold      bool     `json:"fold" yaml:"fold"`
+}
+
+// LoadSchema reads a schema from the given YAML or JSON document and validates it.
+// JSON documents are accepted as is, since JSON is a subset of YAML.
+func LoadSchema(r io.Reader) (*Schema, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	var schema Schema
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&schema); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode schema: %w", err)
+	}
+
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+
+	return &schema, nil
+}
+
+// LoadSchemaFile reads a schema from the YAML or JSON file at the given path.
+func LoadSchemaFile(path string) (*Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema: %w", err)
+	}
+	defer f.Close()
+
+	return LoadSchema(f)
+}
+
+// WithSchema configures the parser to parse query values according to the given schema.
+func WithSchema(schema *Schema) ParserOption {
+	return func(c *config) {
+		c.schema = schema
+	}
+}
+
+// validate checks that the schema is consistent.
+// Field names must be present and unique, columns and tables must be plain identifiers,
+// operators and defaults must be valid, and the limits must not be negative
+// with the default limit not exceeding the maximum.
+func (s *Schema) validate() error {
+	names := make(map[string]struct{}, len(s.Fields))
+
+	for _, field := range s.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("schema field without name")
+		}
+
+		if _, ok := names[field.Name]; ok {
+			return fmt.Errorf("duplicate schema field %s", field.Name)
+		}
+
+		names[field.Name] = struct{}{}
+
+		if !identifierRegexp.MatchString(field.column()) {
+			return fmt.Errorf("schema field %s: bad column %s", field.Name, field.column())
+		}
+
+		if field.Table != "" && !identifierRegexp.MatchString(field.Table) {
+			return fmt.Errorf("schema field %s: bad table %s", field.Name, field.Table)
+		}
+
+		for _, operator := range field.Operators {
+			if _, err := convertOperator(operator); err != nil {
+				return fmt.Errorf("schema field %s: %w", field.Name, err)
+			}
+		}
+
+		if field.Default != "" {
+			if _, err := parseQuery(field.column(), field.Default); err != nil {
+				return fmt.Errorf("schema field %s: %w", field.Name, err)
+			}
+		}
+	}
+
+	for _, column := range s.Sortable {
+		if !identifierRegexp.MatchString(column) {
+			return fmt.Errorf("bad sortable column %s", column)
+		}
+	}
+
+	for _, column := range s.Selectable {
+		if !identifierRegexp.MatchString(column) {
+			return fmt.Errorf("bad selectable column %s", column)
+		}
+	}
+
+	if s.DefaultLimit < 0 || s.MaxLimit < 0 {
+		return fmt.Errorf("schema limits must be greater than 0")
+	}
+
+	if s.MaxLimit > 0 && s.DefaultLimit > s.MaxLimit {
+		return fmt.Errorf("schema default limit must not exceed max limit")
+	}
+
+	return nil
+}
+
+// unknownField returns the error rejecting the given parameter missing from the schema of the parser,
+// suggesting the names of the schema fields closest to it which the given role may filter on,
+// so rejections never disclose fields hidden from the caller, see WithRolePolicy.
+func (c *config) unknownField(role, name string) error {
+	policy, err := c.fieldPolicy(role)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(c.schema.Fields))
+	for _, field := range c.schema.Fields {
+		if policy.allowsField(field.column()) {
+			names = append(names, field.Name)
+		}
+	}
+
+	return reject(ReasonUnknownField, name, "", names, fmt.Sprintf("unknown field %s", name))
+}
+
+// column returns the column filtered by the field.
+func (f SchemaField) column() string {
+	if f.Column == "" {
+		return f.Name
+	}
+
+	return f.Column
+}
+
+// allows reports whether the field accepts the given SQL operator.
+func (f SchemaField) allows(operator string) bool {
+	if len(f.Operators) == 0 {
+		return true
+	}
+
+	for _, allowed := range f.Operators {
+		if op, _ := convertOperator(allowed); op == operator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// operatorNotAllowed returns the error rejecting the given SQL operator for the field,
+// suggesting every operator the field allows.
+func (f SchemaField) operatorNotAllowed(operator string) error {
+	operator = revertOperator(operator)
+
+	return &RejectionError{
+		Reason:      ReasonOperatorNotAllowed,
+		Name:        operator,
+		Field:       f.Name,
+		Suggestions: f.Operators,
+		message:     fmt.Sprintf("operator %s is not allowed for %s", operator, f.Name),
+	}
+}
+
+// ParseValues parses the given query values without a user.
+// See ParseValuesContext for the details.
+func (p *Parser) ParseValues(values url.Values) (*Options, error) {
+	return p.ParseValuesContext(context.Background(), values)
+}
+
+// ParseValuesContext parses the given query values according to the schema of the parser.
+// Every schema field is read from the parameter of the same name, falling back to its default,
+// and a parameter may be repeated to add several conditions on the same field.
+// The "sort" parameter sets the sort columns, which must be sortable according to the schema,
+// and the "fields" parameter sets the selected columns, which must be selectable according to it.
+// The "limit" and "offset" parameters set the pagination, the limit defaults to the default limit
+// of the schema and must not exceed its max limit, which also applies when no limit is set.
+// The pagination is dropped if the parser ignores it, see WithoutPagination.
+// If the parser has a model naming, parameter names and columns in other naming styles are resolved first, see WithModelNaming.
+// Parameters not declared in the schema, operators not allowed for a field,
+// and any parsing or validation error result in an error.
+// If the parser has role policies, the fields and columns must be allowed for the role stored in the context by ContextWithRole.
+// The conditions of the claim filters of the parser are added for the claims of the caller, see WithClaimFilter.
+// If the parser has a cost budget, the options must not exceed the budget left by the deadline of the context,
+// and if it has a rate limit, the caller must not repeat identical options too often, see WithRateLimit.
+// The parsed options are passed to the audit hooks of the parser, see WithAuditHook,
+// and published to its emitter, see WithEmitter.
+func (p *Parser) ParseValuesContext(ctx context.Context, values url.Values) (*Options, error) {
+	cfg := p.config.Load()
+
+	if cfg.schema == nil {
+		return nil, fmt.Errorf("parser has no schema")
+	}
+
+	values, err := cfg.canonicalValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := newOptions(cfg)
+	opt.limit = cfg.schema.DefaultLimit
+	opt.user = UserFromContext(ctx)
+	opt.role = RoleFromContext(ctx)
+
+	known := map[string]struct{}{"limit": {}, "offset": {}, "sort": {}, "fields": {}, "modified_since": {}, "include": {}, "after": {}}
+
+	for _, schemaField := range cfg.schema.Fields {
+		known[schemaField.Name] = struct{}{}
+
+		queries := values[schemaField.Name]
+		if len(queries) == 0 && schemaField.Default != "" {
+			queries = []string{schemaField.Default}
+		}
+
+		for _, query := range queries {
+			if len(query) == 0 {
+				continue
+			}
+
+			field, err := parseQuery(schemaField.column(), query)
+			if err != nil {
+				return nil, err
+			}
+
+			if !schemaField.allows(field.Operator) {
+				return nil, schemaField.operatorNotAllowed(field.Operator)
+			}
+
+			field.Class = schemaField.Class
+			field.Table = schemaField.Table
+			field.Fold = schemaField.Fold
+
+			if err := opt.addField(field); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for name := range values {
+		if _, ok := known[name]; !ok {
+			return nil, cfg.unknownField(opt.role, name)
+		}
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		sorts, err := parseSort(sort, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		opt.sorts = sorts
+	}
+
+	if fields := values.Get("fields"); fields != "" {
+		selects, err := parseSelect(fields, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		opt.selects = selects
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse limit")
+		}
+
+		if l < 0 {
+			return nil, fmt.Errorf("limit must be greater than 0")
+		}
+
+		opt.limit = l
+	}
+
+	if offset := values.Get("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse offset")
+		}
+
+		if o < 0 {
+			return nil, fmt.Errorf("offset must be greater than 0")
+		}
+
+		opt.offset = o
+	}
+
+	if include := values.Get("include"); include != "" {
+		if err := opt.setIncludes(include); err != nil {
+			return nil, err
+		}
+	}
+
+	if modifiedSince := values.Get("modified_since"); modifiedSince != "" {
+		if err := opt.setModifiedSince(modifiedSince); err != nil {
+			return nil, err
+		}
+	}
+
+	if after := values.Get("after"); after != "" {
+		if err := opt.setAfter(after); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := opt.conform(schemaAllowlist(cfg.schema)); err != nil {
+		return nil, err
+	}
+
+	if err := p.finish(ctx, opt); err != nil {
+		return nil, err
+	}
+
+	return opt, nil
+}
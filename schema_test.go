@@ -0,0 +1,38 @@
+package qparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadSchemaColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    string
+	}{
+		{name: "name", schema: "fields:\n  - name: first_name\n"},
+		{name: "column", schema: "fields:\n  - name: first-name\n    column: first_name\n"},
+		{name: "qualified", schema: "fields:\n  - name: name\n    column: users.name\n"},
+		{name: "bad name", schema: "fields:\n  - name: first-name\n", err: "schema field first-name: bad column first-name"},
+		{name: "bad column", schema: "fields:\n  - name: name\n    column: name; --\n", err: "schema field name: bad column name; --"},
+		{name: "bad table", schema: "fields:\n  - name: name\n    table: users u\n", err: "schema field name: bad table users u"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSchema(strings.NewReader(tt.schema))
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("got error %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
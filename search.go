@@ -0,0 +1,150 @@
+package qparser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSearchNotFound is returned by a SearchStore when the requested saved search does not exist.
+var ErrSearchNotFound = errors.New("saved search not found")
+
+// SavedSearch is a named search saved by a user, holding its options serialized by Options.MarshalJSON.
+// The name of a search is unique per owner.
+type SavedSearch struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Owner     string    `gorm:"size:255;not null;uniqueIndex:idx_saved_searches_owner_name" json:"owner"`
+	Name      string    `gorm:"size:255;not null;uniqueIndex:idx_saved_searches_owner_name" json:"name"`
+	Options   []byte    `gorm:"not null" json:"options"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SearchStore persists the saved searches of users, e.g. for "save this filter" features.
+type SearchStore interface {
+	// Save stores the search, replacing the options of the search of its owner with the same name, if any.
+	Save(ctx context.Context, search *SavedSearch) error
+	// Get returns the search of the owner with the given name, or ErrSearchNotFound.
+	Get(ctx context.Context, owner, name string) (*SavedSearch, error)
+	// List returns the searches of the owner, ordered by name.
+	List(ctx context.Context, owner string) ([]SavedSearch, error)
+	// Delete deletes the search of the owner with the given name, or returns ErrSearchNotFound.
+	Delete(ctx context.Context, owner, name string) error
+}
+
+// GormSearchStore is a SearchStore keeping the saved searches in the saved_searches table of a GORM database.
+type GormSearchStore struct {
+	db *gorm.DB
+}
+
+// NewGormSearchStore creates a new GormSearchStore using the given database.
+// The table must exist, see Migrate.
+func NewGormSearchStore(db *gorm.DB) *GormSearchStore {
+	return &GormSearchStore{db: db}
+}
+
+// Migrate creates or updates the saved_searches table.
+func (s *GormSearchStore) Migrate() error {
+	return s.db.AutoMigrate(&SavedSearch{})
+}
+
+// Save stores the search, replacing the options of the search of its owner with the same name, if any.
+func (s *GormSearchStore) Save(ctx context.Context, search *SavedSearch) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"options", "updated_at"}),
+	}).Create(search).Error
+}
+
+// Get returns the search of the owner with the given name, or ErrSearchNotFound.
+func (s *GormSearchStore) Get(ctx context.Context, owner, name string) (*SavedSearch, error) {
+	var search SavedSearch
+
+	err := s.db.WithContext(ctx).Where("owner = ? AND name = ?", owner, name).Take(&search).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSearchNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &search, nil
+}
+
+// List returns the searches of the owner, ordered by name.
+func (s *GormSearchStore) List(ctx context.Context, owner string) ([]SavedSearch, error) {
+	searches := make([]SavedSearch, 0)
+
+	if err := s.db.WithContext(ctx).Where("owner = ?", owner).Order("name").Find(&searches).Error; err != nil {
+		return nil, err
+	}
+
+	return searches, nil
+}
+
+// Delete deletes the search of the owner with the given name, or returns ErrSearchNotFound.
+func (s *GormSearchStore) Delete(ctx context.Context, owner, name string) error {
+	result := s.db.WithContext(ctx).Where("owner = ? AND name = ?", owner, name).Delete(&SavedSearch{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrSearchNotFound
+	}
+
+	return nil
+}
+
+// SaveSearch serializes the options and saves them in the store as the search of the owner with the given name.
+func SaveSearch(ctx context.Context, store SearchStore, owner, name string, options *Options) (*SavedSearch, error) {
+	if name == "" {
+		return nil, fmt.Errorf("saved search must have a name")
+	}
+
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode options: %w", err)
+	}
+
+	search := &SavedSearch{
+		Owner:   owner,
+		Name:    name,
+		Options: encoded,
+	}
+
+	if err := store.Save(ctx, search); err != nil {
+		return nil, fmt.Errorf("failed to save search %s: %w", name, err)
+	}
+
+	return search, nil
+}
+
+// LoadSearch returns the options of the search of the owner with the given name, deserialized by the parser,
+// so they are validated again with its current schema and configuration and the user and role stored in the context,
+// see UnmarshalOptionsContext. The parser must have a schema.
+func (p *Parser) LoadSearch(ctx context.Context, store SearchStore, owner, name string) (*Options, error) {
+	search, err := store.Get(ctx, owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.UnmarshalOptionsContext(ctx, search.Options)
+}
+
+// ApplySearch loads the search of the owner with the given name, see LoadSearch,
+// and applies its options to the given GORM transaction.
+func (p *Parser) ApplySearch(ctx context.Context, store SearchStore, tx *gorm.DB, owner, name string) (*gorm.DB, error) {
+	options, err := p.LoadSearch(ctx, store, owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return options.Apply(tx.WithContext(ctx)), nil
+}
@@ -0,0 +1,238 @@
+package qparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// optionsVersion is the version of the serialized options written by MarshalJSON.
+const optionsVersion = 1
+
+// optionsUpgrades upgrade serialized options from the version they are registered for to the next one.
+// Every change of the serialized form increments optionsVersion and registers the upgrade from the previous version.
+var optionsUpgrades = map[int]func(data []byte) ([]byte, error){}
+
+// encodedOptions is the serialized form of Options.
+type encodedOptions struct {
+	Version int            `json:"version"`
+	Fields  []encodedField `json:"fields"`
+	Sorts   []encodedSort  `json:"sorts,omitempty"`
+	Selects []string       `json:"selects,omitempty"`
+	Limit   int            `json:"limit,omitempty"`
+	Offset  int            `json:"offset,omitempty"`
+	Since   string         `json:"modified_since,omitempty"`
+	After   string         `json:"after,omitempty"`
+	Include string         `json:"include,omitempty"`
+}
+
+// encodedField is the serialized form of a Field, with the operator in its query form.
+// The classification, case folding and encryption of the field are not serialized,
+// they are taken from the configuration of the parser when the options are deserialized.
+type encodedField struct {
+	Name     string `json:"name"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+	Table    string `json:"table,omitempty"`
+}
+
+// encodedSort is the serialized form of a Sort.
+type encodedSort struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc,omitempty"`
+}
+
+// MarshalJSON serializes the fields, sorting, selected columns and pagination of the options
+// as a versioned JSON document, e.g. to store them in queued jobs or caches.
+// The user, the parser configuration and the conditions added by claim filters and middleware are not serialized,
+// they are added again for the caller when the options are deserialized, see Parser.UnmarshalOptions.
+func (o *Options) MarshalJSON() ([]byte, error) {
+	encoded := encodedOptions{
+		Version: optionsVersion,
+		Fields:  make([]encodedField, 0, len(o.fields)),
+		Selects: o.selects,
+		Limit:   o.limit,
+		Offset:  o.offset,
+		Include: o.include,
+	}
+
+	if o.since != nil {
+		encoded.Since = o.since.raw
+	}
+
+	if o.after != nil {
+		encoded.After = o.after.raw
+	}
+
+	for _, field := range o.fields {
+		if field.mandatory {
+			continue
+		}
+
+		encoded.Fields = append(encoded.Fields, encodedField{
+			Name:     field.Name,
+			Operator: revertOperator(field.Operator),
+			Value:    field.Value,
+			Table:    field.Table,
+		})
+	}
+
+	for _, sort := range o.sorts {
+		encoded.Sorts = append(encoded.Sorts, encodedSort{Column: sort.Column, Desc: sort.Desc})
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalOptions deserializes options serialized by MarshalJSON without a user.
+// See UnmarshalOptionsContext for the details.
+func (p *Parser) UnmarshalOptions(data []byte) (*Options, error) {
+	return p.UnmarshalOptionsContext(context.Background(), data)
+}
+
+// UnmarshalOptionsContext deserializes options serialized by MarshalJSON with the configuration of the parser.
+// Documents written by older versions are upgraded automatically, and documents without a version
+// or written by newer versions result in an error.
+// A serialized document is untrusted input, so it is validated like parsed query values against the schema
+// of the parser: the fields and their operators must be declared by it, the sort and selected columns
+// must be sortable and selectable, and the limit must not exceed its max limit.
+// The classification and case folding of the fields are taken from the schema instead of the document,
+// and whether their values are encrypted from the encrypted fields of the parser, see WithEncryptedField.
+// Parsers without a schema cannot validate a document and return an error,
+// use UnmarshalStructOptionsContext for options parsed from a filter struct.
+// The fields are then validated and normalized again as if they were parsed, including the policy of the parser
+// for the user stored in the context by ContextWithUser and the role policies of the parser
+// for the role stored in it by ContextWithRole, so a document cannot bypass them.
+// The deserialized options then complete the pipeline of parsed options, so they are constrained like them:
+// the conditions of the claim filters of the parser are added for the claims of the caller, see WithClaimFilter,
+// the middleware of the parser is run, see Parser.Use, and the cost budget, rate limit, audit hooks
+// and emitter of the parser apply, as for ParseValuesContext.
+func (p *Parser) UnmarshalOptionsContext(ctx context.Context, data []byte) (*Options, error) {
+	cfg := p.config.Load()
+
+	if cfg.schema == nil {
+		return nil, fmt.Errorf("options cannot be deserialized without a schema, use UnmarshalStructOptionsContext")
+	}
+
+	return p.unmarshalOptions(ctx, cfg, data, schemaAllowlist(cfg.schema))
+}
+
+// UnmarshalStructOptions deserializes options parsed from the given filter struct without a user.
+// See UnmarshalStructOptionsContext for the details.
+func (p *Parser) UnmarshalStructOptions(data []byte, filter interface{}) (*Options, error) {
+	return p.UnmarshalStructOptionsContext(context.Background(), data, filter)
+}
+
+// UnmarshalStructOptionsContext deserializes options serialized by MarshalJSON like UnmarshalOptionsContext,
+// validating them against the "query" tags of the given filter struct instead of the schema of the parser:
+// the fields must be tagged in the struct, and the sort and selected columns must be allowed by the "allow" option
+// of the "sort" and "fields" tags, see Parser.ParseStruct.
+func (p *Parser) UnmarshalStructOptionsContext(ctx context.Context, data []byte, filter interface{}) (*Options, error) {
+	a, err := structAllowlist(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.unmarshalOptions(ctx, p.config.Load(), data, a)
+}
+
+// unmarshalOptions deserializes the given document with the given configuration, within the given allowlist.
+func (p *Parser) unmarshalOptions(ctx context.Context, cfg *config, data []byte, a *allowlist) (*Options, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode options: %w", err)
+	}
+
+	version := header.Version
+	if version < 1 || version > optionsVersion {
+		return nil, fmt.Errorf("unsupported options version %d", version)
+	}
+
+	for ; version < optionsVersion; version++ {
+		upgraded, err := optionsUpgrades[version](data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade options from version %d: %w", version, err)
+		}
+
+		data = upgraded
+	}
+
+	var encoded encodedOptions
+
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode options: %w", err)
+	}
+
+	opt := newOptions(cfg)
+	opt.limit = encoded.Limit
+	opt.offset = encoded.Offset
+	opt.user = UserFromContext(ctx)
+	opt.role = RoleFromContext(ctx)
+
+	policy, err := opt.fieldPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, serialized := range encoded.Fields {
+		operator, err := convertOperator(serialized.Operator)
+		if err != nil {
+			return nil, err
+		}
+
+		field := newField(Field{
+			Name:     serialized.Name,
+			Value:    serialized.Value,
+			Operator: operator,
+			Table:    serialized.Table,
+		})
+
+		if err := a.field(field, policy); err != nil {
+			return nil, err
+		}
+
+		// serialized values of encrypted fields are already encrypted, see WithEncryptedField
+		_, field.Encrypted = cfg.encryptions[field.Name]
+
+		if err := opt.addField(field); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sort := range encoded.Sorts {
+		opt.sorts = append(opt.sorts, Sort{Column: sort.Column, Desc: sort.Desc})
+	}
+
+	opt.selects = encoded.Selects
+
+	if err := opt.conform(a); err != nil {
+		return nil, err
+	}
+
+	if encoded.Since != "" {
+		if err := opt.setModifiedSince(encoded.Since); err != nil {
+			return nil, err
+		}
+	}
+
+	if encoded.Include != "" {
+		if err := opt.setIncludes(encoded.Include); err != nil {
+			return nil, err
+		}
+	}
+
+	if encoded.After != "" {
+		if err := opt.setAfter(encoded.After); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.finish(ctx, opt); err != nil {
+		return nil, err
+	}
+
+	return opt, nil
+}
@@ -0,0 +1,160 @@
+package qparser
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalTamperedDocuments(t *testing.T) {
+	p := NewParser(
+		WithSchema(&Schema{
+			Fields: []SchemaField{
+				{Name: "name", Operators: []string{"eq", "like"}},
+				{Name: "age", Table: "profiles"},
+				{Name: "salary"},
+			},
+			Sortable:   []string{"name", "salary"},
+			Selectable: []string{"name", "salary"},
+			MaxLimit:   100,
+		}),
+		WithRolePolicy(map[string]FieldPolicy{
+			"admin": {},
+			"public": {
+				Fields:     map[string][]string{"name": nil, "age": nil},
+				Sortable:   []string{"name"},
+				Selectable: []string{"name"},
+			},
+		}),
+	)
+
+	tests := []struct {
+		name     string
+		role     string
+		document string
+		want     string
+		err      string
+	}{
+		{
+			name:     "valid",
+			role:     "public",
+			document: `{"version":1,"fields":[{"name":"name","operator":"eq","value":"bob"}],"limit":10}`,
+			want:     "WHERE name = ? LIMIT 10",
+		},
+		{
+			name:     "unknown field",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"password","operator":"eq","value":"x"}]}`,
+			err:      "unknown field password",
+		},
+		{
+			name:     "injected name",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"name = name OR 1","operator":"eq","value":"1"}]}`,
+			err:      "unknown field name = name OR 1",
+		},
+		{
+			name:     "operator not declared",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"name","operator":"gt","value":"a"}]}`,
+			err:      "operator gt is not allowed for name, did you mean eq or like?",
+		},
+		{
+			name:     "unknown operator",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"name","operator":"= 1 OR","value":"a"}]}`,
+			err:      "bad operator = 1 OR",
+		},
+		{
+			name:     "forbidden field",
+			role:     "public",
+			document: `{"version":1,"fields":[{"name":"salary","operator":"gt","value":"1"}]}`,
+			err:      "filtering on salary is not allowed",
+		},
+		{
+			name:     "forbidden sort",
+			role:     "public",
+			document: `{"version":1,"fields":[],"sorts":[{"column":"salary","desc":true}]}`,
+			err:      "sorting by salary is not allowed",
+		},
+		{
+			name:     "forbidden select",
+			role:     "public",
+			document: `{"version":1,"fields":[],"selects":["salary"]}`,
+			err:      "selecting salary is not allowed",
+		},
+		{
+			name:     "undeclared table",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"age","operator":"gt","value":"1","table":"secrets"}]}`,
+			err:      "unknown field age",
+		},
+		{
+			name:     "declared table",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"age","operator":"gt","value":"1","table":"profiles"}]}`,
+			want:     "WHERE profiles.age > ? LIMIT 100",
+		},
+		{
+			name:     "folding ignored",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"name","operator":"like","value":"%bob%","fold":true,"class":"public"}]}`,
+			want:     "WHERE name ILIKE ? LIMIT 100",
+		},
+		{
+			name:     "limit above max",
+			role:     "admin",
+			document: `{"version":1,"fields":[],"limit":1000}`,
+			err:      "limit must not exceed 100",
+		},
+		{
+			name:     "negative offset",
+			role:     "admin",
+			document: `{"version":1,"fields":[],"offset":-1}`,
+			err:      "offset must be greater than 0",
+		},
+		{
+			name:     "too many branches",
+			role:     "admin",
+			document: `{"version":1,"fields":[{"name":"salary","operator":"rng","value":"` + strings.Repeat("rng:1 to 2|", 10) + `rng:1 to 2"}]}`,
+			err:      "rng on salary combines 11 conditions with OR, at most 10 are allowed",
+		},
+		{
+			name:     "newer version",
+			role:     "admin",
+			document: `{"version":2,"fields":[]}`,
+			err:      "unsupported options version 2",
+		},
+		{
+			name:     "missing role",
+			document: `{"version":1,"fields":[]}`,
+			err:      `unknown role ""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := p.UnmarshalOptionsContext(ContextWithRole(context.Background(), tt.role), []byte(tt.document))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v, want %s", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query, _, err := opts.ToSQL(DialectPostgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if query != tt.want {
+				t.Fatalf("got %q, want %q", query, tt.want)
+			}
+		})
+	}
+}
@@ -1,71 +1,268 @@
 package qparser
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
 )
 
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
 // parseQuery parses the given query string and returns a Field object representing the parsed query.
 // The query string should be in the format "operator:value".
+// Only the first colon separates the operator, so values may contain colons, e.g. "eq:09:30".
 // The name parameter specifies the name of the field being queried.
 // If the query string is not in the correct format, an error is returned.
 func parseQuery(name, query string) (*Field, error) {
-	args := strings.Split(query, ":")
-	if len(args) < 2 {
+	op, value, ok := strings.Cut(query, ":")
+	if !ok {
 		return nil, fmt.Errorf("bad query, use operator:value")
 	}
 
-	if len(strings.Split(args[0], " ")) > 1 {
+	if len(strings.Split(op, " ")) > 1 {
 		return nil, fmt.Errorf("bad query, use operator:value")
 	}
 
-	operator, err := convertOperator(args[0])
+	operator, err := convertOperator(op)
 	if err != nil {
 		return nil, err
 
 	}
 
-	return &Field{
+	return newField(Field{
 		Name:     name,
 		Operator: operator,
-		Value:    strings.Join(args[1:], " "),
-	}, nil
+		Value:    value,
+	}), nil
+}
+
+// parseRanges parses the value of the "range" operator into the bounds of its ranges.
+// A value holds one or more ranges separated by "|", each further range repeating the operator,
+// e.g. "09:00 to 12:00|rng:13:00 to 17:00". The legacy "value1:to:value2" form is accepted as well.
+// The bounds are returned as consecutive pairs.
+func parseRanges(value string) ([]string, error) {
+	ranges := strings.Split(value, "|")
+	bounds := make([]string, 0, len(ranges)*2)
+
+	for i, r := range ranges {
+		if i > 0 {
+			var ok bool
+			if r, ok = strings.CutPrefix(r, operatorRange+":"); !ok {
+				return nil, fmt.Errorf("invalid usage of operator rng. rng:value1 to value2|rng:value3 to value4")
+			}
+		}
+
+		if !strings.Contains(r, " to ") {
+			r = strings.Replace(r, ":to:", " to ", 1)
+		}
+
+		args := strings.Split(r, " to ")
+		if len(args) != 2 {
+			return nil, fmt.Errorf("invalid usage of operator rng. rng:value1 to value2")
+		}
+
+		bounds = append(bounds, args[0], args[1])
+	}
+
+	return bounds, nil
+}
+
+// parseElements parses the comma-separated value of the "anyof" and "allof" operators into its elements,
+// e.g. "go,sql". Empty and repeated elements are dropped, and at least one element is required.
+func parseElements(value string) ([]string, error) {
+	elements := make([]string, 0)
+
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		if element == "" || contains(elements, element) {
+			continue
+		}
+
+		elements = append(elements, element)
+	}
+
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("invalid usage of operator anyof/allof. anyof:value1,value2")
+	}
+
+	return elements, nil
+}
+
+// parseSort parses the given comma-separated list of sort columns.
+// A column prefixed with "-" is sorted in descending order, e.g. "name,-created_at".
+// Columns must be plain identifiers, and if allowed is not nil, they must be part of it.
+// If the value is empty, no sort columns are returned.
+func parseSort(value string, allowed []string) ([]Sort, error) {
+	sorts := make([]Sort, 0)
+
+	for _, column := range strings.Split(value, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+
+		sort := Sort{Column: strings.TrimPrefix(column, "-"), Desc: strings.HasPrefix(column, "-")}
+
+		if !identifierRegexp.MatchString(sort.Column) {
+			return nil, fmt.Errorf("bad sort column %s", sort.Column)
+		}
+
+		if allowed != nil && !contains(allowed, sort.Column) {
+			return nil, reject(ReasonSortNotAllowed, sort.Column, "", allowed, fmt.Sprintf("sorting by %s is not allowed", sort.Column))
+		}
+
+		sorts = append(sorts, sort)
+	}
+
+	return sorts, nil
+}
+
+// parseSelect parses the given comma-separated list of selected columns, e.g. "id,name".
+// Columns must be plain identifiers, and if allowed is not nil, they must be part of it.
+// If the value is empty, no columns are returned and every column is selected.
+func parseSelect(value string, allowed []string) ([]string, error) {
+	selects := make([]string, 0)
+
+	for _, column := range strings.Split(value, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+
+		if !identifierRegexp.MatchString(column) {
+			return nil, fmt.Errorf("bad field %s", column)
+		}
+
+		if allowed != nil && !contains(allowed, column) {
+			return nil, reject(ReasonSelectNotAllowed, column, "", allowed, fmt.Sprintf("selecting %s is not allowed", column))
+		}
+
+		selects = append(selects, column)
+	}
+
+	return selects, nil
+}
+
+// contains reports whether the given value is part of the given values.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTag splits the given "query" tag into the name and its comma-separated options.
+// Options are either flags like "fold", stored with an empty value, or "key=value" pairs.
+func parseTag(tag string) (string, map[string]string) {
+	parts := strings.Split(tag, ",")
+	options := make(map[string]string, len(parts)-1)
+
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		options[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return parts[0], options
+}
+
+// hasOption reports whether the given flag is present in the tag options.
+func hasOption(options map[string]string, flag string) bool {
+	_, ok := options[flag]
+
+	return ok
 }
 
-// ParseStruct parses the given data and returns an Options struct and an error.
+// ParseStruct parses the given data with the default parser.
+// See Parser.ParseStruct for the supported tags.
+func ParseStruct(data interface{}) (*Options, error) {
+	return defaultParser.ParseStruct(data)
+}
+
+// ParseStruct parses the given data without a user.
+// See ParseStructContext for the supported tags.
+func (p *Parser) ParseStruct(data interface{}) (*Options, error) {
+	return p.ParseStructContext(context.Background(), data)
+}
+
+// ParseStructContext parses the given data and returns an Options struct and an error.
 // It iterates over the fields of the data structure and populates the Options struct accordingly.
 // The "query" tag is used to specify the behavior for each field.
+// The first part of the tag is the name of the field, the following comma-separated options
+// configure it further, e.g. "class=pii" classifies the field, "table=accounts" declares its owning table
+// and "fold" compares the field with LOWER on both sides for the "like" operator.
 // The "limit" tag is used to set the limit value for the Options struct.
 // The "offset" tag is used to set the offset value for the Options struct.
+// Both accept integers, integral floats and strings holding integers, see intValue, and are skipped when empty.
+// The pagination is dropped if the parser ignores it, see WithoutPagination.
+// The "sort" tag is used to set the sort columns, optionally restricted with the "allow" option, e.g. "sort,allow=name|age".
+// The "fields" tag is used to set the selected columns, optionally restricted with the "allow" option as well.
+// Boolean fields add an equality condition on their value, plain booleans only when they are true
+// since false cannot be told apart from unset, use a *bool field to filter on false.
 // For other fields, the parseQuery function is used to parse the field value and add it to the Options struct.
+// Values are resolved through pointers and interfaces, nil values, untagged fields and fields tagged "-" are skipped,
+// fmt.Stringer implementations are rendered with String and custom types with their underlying value,
+// e.g. a `type Status string` field holding "eq:active".
+// The data may be a struct or a pointer to a struct.
+// The user stored in the context by ContextWithUser is used to authorize the fields and to scope Apply.
+// If the parser has role policies, the fields and columns must be allowed for the role stored in the context by ContextWithRole.
+// The conditions of the claim filters of the parser are added for the claims of the caller, see WithClaimFilter.
+// If the parser has a cost budget, the options must not exceed the budget left by the deadline of the context,
+// and if it has a rate limit, the caller must not repeat identical options too often, see WithRateLimit.
+// The parsed options are passed to the audit hooks of the parser, see WithAuditHook,
+// and published to its emitter, see WithEmitter.
 // If any parsing or validation error occurs, an error is returned.
-func ParseStruct(data interface{}) (*Options, error) {
-	filterValue := reflect.ValueOf(data)
-	filterType := filterValue.Type()
+func (p *Parser) ParseStructContext(ctx context.Context, data interface{}) (*Options, error) {
+	cfg := p.config.Load()
 
-	opt := &Options{
-		limit:  0,
-		offset: 0,
-		fields: make([]*Field, 0),
+	filterValue, ok := indirect(reflect.ValueOf(data))
+	if !ok || filterValue.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("data must be a struct or a pointer to a struct")
 	}
 
+	filterType := filterValue.Type()
+
+	opt := newOptions(cfg)
+	opt.user = UserFromContext(ctx)
+	opt.role = RoleFromContext(ctx)
+
 	for i := 0; i < filterType.NumField(); i++ {
 		field := filterType.Field(i)
 		value := filterValue.Field(i)
 
-		if value.Kind() == reflect.Ptr && value.IsNil() {
+		if !field.IsExported() || field.Tag.Get("query") == "" || field.Tag.Get("query") == "-" {
 			continue
 		}
 
-		tag := field.Tag.Get("query")
-		fieldValue := reflect.Indirect(value).Interface()
+		resolved, ok := indirect(value)
+		if !ok {
+			continue
+		}
+
+		if value.Kind() == reflect.Bool && !value.Bool() {
+			continue
+		}
+
+		tag, tagOptions := parseTag(field.Tag.Get("query"))
+		fieldValueStr := fieldString(value)
 
 		switch tag {
 		case "limit":
-			l, ok := fieldValue.(int)
+			if fieldValueStr == "" {
+				continue
+			}
+
+			l, ok := intValue(resolved)
 
 			if !ok {
 				return nil, fmt.Errorf("failed to parse limit")
@@ -79,7 +276,11 @@ func ParseStruct(data interface{}) (*Options, error) {
 
 			continue
 		case "offset":
-			o, ok := fieldValue.(int)
+			if fieldValueStr == "" {
+				continue
+			}
+
+			o, ok := intValue(resolved)
 
 			if !ok {
 				return nil, fmt.Errorf("failed to parse offset")
@@ -91,20 +292,101 @@ func ParseStruct(data interface{}) (*Options, error) {
 
 			opt.offset = o
 
+			continue
+		case "sort":
+			var allowed []string
+			if allow, ok := tagOptions["allow"]; ok {
+				allowed = strings.Split(allow, "|")
+			}
+
+			allowed, err := opt.sortable(allowed)
+			if err != nil {
+				return nil, err
+			}
+
+			sorts, err := parseSort(fieldValueStr, allowed)
+			if err != nil {
+				return nil, err
+			}
+
+			opt.sorts = sorts
+
+			continue
+		case "fields":
+			var allowed []string
+			if allow, ok := tagOptions["allow"]; ok {
+				allowed = strings.Split(allow, "|")
+			}
+
+			allowed, err := opt.selectable(allowed)
+			if err != nil {
+				return nil, err
+			}
+
+			selects, err := parseSelect(fieldValueStr, allowed)
+			if err != nil {
+				return nil, err
+			}
+
+			opt.selects = selects
+
+			continue
+		case "after":
+			if fieldValueStr == "" {
+				continue
+			}
+
+			if err := opt.setAfter(fieldValueStr); err != nil {
+				return nil, err
+			}
+
+			continue
+		case "include":
+			if fieldValueStr == "" {
+				continue
+			}
+
+			if err := opt.setIncludes(fieldValueStr); err != nil {
+				return nil, err
+			}
+
+			continue
+		case "modified_since":
+			if t, ok := resolved.Interface().(time.Time); ok {
+				if t.IsZero() {
+					continue
+				}
+
+				fieldValueStr = t.Format(time.RFC3339Nano)
+			}
+
+			if fieldValueStr == "" {
+				continue
+			}
+
+			if err := opt.setModifiedSince(fieldValueStr); err != nil {
+				return nil, err
+			}
+
 			continue
 		}
 
-		switch field.Type {
-		case reflect.TypeOf((*bool)(nil)):
+		switch resolved.Kind() {
+		case reflect.Bool:
 			{
-				if err := opt.AddField(tag, fmt.Sprint(fieldValue), operatorEqual); err != nil {
+				if err := opt.addField(newField(Field{
+					Name:     tag,
+					Value:    fieldValueStr,
+					Operator: sqlOperatorEqual,
+					Class:    tagOptions["class"],
+					Table:    tagOptions["table"],
+					Fold:     hasOption(tagOptions, "fold"),
+				})); err != nil {
 					return nil, err
 				}
 			}
 		default:
 			{
-				fieldValueStr := fmt.Sprint(fieldValue)
-
 				if len(fieldValueStr) == 0 {
 					continue
 				}
@@ -114,16 +396,155 @@ func ParseStruct(data interface{}) (*Options, error) {
 					return nil, err
 				}
 
-				if err := opt.AddField(field.Name, field.Value, field.Operator); err != nil {
+				field.Class = tagOptions["class"]
+				field.Table = tagOptions["table"]
+				field.Fold = hasOption(tagOptions, "fold")
+
+				if err := opt.addField(field); err != nil {
 					return nil, err
 				}
 			}
 		}
 	}
 
+	if err := p.finish(ctx, opt); err != nil {
+		return nil, err
+	}
+
 	return opt, nil
 }
 
+// finish completes the options once parsed or deserialized, in the same order for every entry point.
+// The cursors are checked, the pagination is dropped if the parser ignores it, see WithoutPagination,
+// the columns are authorized for the role of the options, the conditions must stay within the limits of the parser,
+// see WithConditionLimits, and the conditions of the claim filters are added.
+// The middleware of the parser is then run, see Parser.Use, and the options must stay within the cost budget
+// and the rate limit of the parser before they are passed to its audit hooks and published to its emitter.
+func (p *Parser) finish(ctx context.Context, opt *Options) error {
+	if err := opt.checkModifiedSince(); err != nil {
+		return err
+	}
+
+	if err := opt.checkAfter(); err != nil {
+		return err
+	}
+
+	if opt.config.paginationDisabled {
+		opt.limit, opt.offset = 0, 0
+	}
+
+	if err := opt.authorizeColumns(); err != nil {
+		return err
+	}
+
+	if err := opt.checkConditions(); err != nil {
+		return err
+	}
+
+	if err := opt.applyClaims(ctx); err != nil {
+		return err
+	}
+
+	if err := p.runMiddleware(opt); err != nil {
+		return err
+	}
+
+	if err := opt.checkBudget(ctx); err != nil {
+		return err
+	}
+
+	if err := opt.checkRate(ctx); err != nil {
+		return err
+	}
+
+	opt.audit(ctx)
+	opt.emit()
+
+	return nil
+}
+
+// indirect resolves the pointers and interfaces wrapping the given value.
+// ok is false if the value or any of its wrappers is nil.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		v = v.Elem()
+	}
+
+	return v, v.IsValid()
+}
+
+// intValue returns the integer held by the given resolved value of a "limit" or "offset" field.
+// Integers, floats without a fractional part and strings holding an integer are accepted,
+// since form binding into optional fields produces pointers and some binders produce strings.
+func intValue(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f != float64(int(f)) {
+			return 0, false
+		}
+
+		return int(f), true
+	case reflect.String:
+		i, err := strconv.Atoi(v.String())
+
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// fieldString returns the string form of the given struct field value, resolving pointers and interfaces.
+// fmt.Stringer implementations are rendered with String, values of other types with their underlying value,
+// so custom string-kinded types render as the string they hold instead of a Go-syntax representation.
+// An empty string is returned if the value is nil.
+func fieldString(v reflect.Value) string {
+	for {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return ""
+		}
+
+		if v.CanInterface() {
+			if stringer, ok := v.Interface().(fmt.Stringer); ok {
+				return stringer.String()
+			}
+		}
+
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
 // validateOperator validates the given operator string.
 // It checks if the operator is one of the supported SQL operators.
 // If the operator is not supported, it returns an error.
@@ -137,6 +558,14 @@ func validateOperator(operator string) error {
 	case sqlOperatorLowerThanEqual:
 	case sqlOperatorLike:
 	case sqlOperatorRange:
+	case sqlOperatorExists:
+	case sqlOperatorNotExists:
+	case sqlOperatorPeriod:
+	case sqlOperatorAnyOf:
+	case sqlOperatorAllOf:
+	case sqlOperatorWords:
+	case sqlOperatorInSubquery:
+	case sqlOperatorNotInSubquery:
 	default:
 		return fmt.Errorf("bad operator")
 	}
@@ -163,71 +592,460 @@ func convertOperator(operator string) (string, error) {
 		return sqlOperatorLike, nil
 	case operatorRange:
 		return sqlOperatorRange, nil
+	case operatorHas:
+		return sqlOperatorExists, nil
+	case operatorHasNot:
+		return sqlOperatorNotExists, nil
+	case operatorPeriod:
+		return sqlOperatorPeriod, nil
+	case operatorAnyOf:
+		return sqlOperatorAnyOf, nil
+	case operatorAllOf:
+		return sqlOperatorAllOf, nil
+	case operatorWords:
+		return sqlOperatorWords, nil
+	case operatorInSubquery:
+		return sqlOperatorInSubquery, nil
+	case operatorNotInSubquery:
+		return sqlOperatorNotInSubquery, nil
+	default:
+		return "", reject(ReasonUnknownOperator, operator, "", queryOperators, fmt.Sprintf("bad operator %s", operator))
+	}
+}
+
+// revertOperator converts a given SQL operator back to its query form.
+// It returns an empty string if the operator is not recognized.
+func revertOperator(operator string) string {
+	switch operator {
+	case sqlOperatorEqual:
+		return operatorEqual
+	case sqlOperatorNotEqual:
+		return operatorNotEqual
+	case sqlOperatorGreaterThan:
+		return operatorGreaterThan
+	case sqlOperatorGreaterThanEqual:
+		return operatorGreaterThanEqual
+	case sqlOperatorLowerThan:
+		return operatorLowerThan
+	case sqlOperatorLowerThanEqual:
+		return operatorLowerThanEqual
+	case sqlOperatorLike:
+		return operatorLike
+	case sqlOperatorRange:
+		return operatorRange
+	case sqlOperatorExists:
+		return operatorHas
+	case sqlOperatorNotExists:
+		return operatorHasNot
+	case sqlOperatorPeriod:
+		return operatorPeriod
+	case sqlOperatorAnyOf:
+		return operatorAnyOf
+	case sqlOperatorAllOf:
+		return operatorAllOf
+	case sqlOperatorWords:
+		return operatorWords
+	case sqlOperatorInSubquery:
+		return operatorInSubquery
+	case sqlOperatorNotInSubquery:
+		return operatorNotInSubquery
 	default:
-		return "", fmt.Errorf("bad operator")
+		return ""
 	}
 }
 
 // AddField adds a field to the Options struct.
 // It takes the name, value, and operator of the field as parameters.
+// See addField for the validation and normalization applied to the field.
+// Returns nil if successful, otherwise returns an error.
+func (o *Options) AddField(name, value, operator string) error {
+	return o.addField(newField(Field{
+		Name:     name,
+		Value:    value,
+		Operator: operator,
+	}))
+}
+
+// addField validates, normalizes and appends the given field to the Options struct.
 // The operator is validated, and if it is invalid, an error is returned.
+// If the parser has a policy, the field and operator must be allowed for the user of the options,
+// and if it has role policies, they must be allowed for the role of the options.
+// If the field is classified, the classification hook registered on the parser may refuse or transform it.
+// If the field is encrypted, its values are encrypted once normalized, see WithEncryptedField.
+// If the operator is "period", the period must be known, and it is only resolved to its bounds
+// when the conditions are built, see periodCondition.
 // If the operator is "like" and the value does not contain "%", the value is modified to include "%" at the beginning and end.
-// If the operator is "range", the value is split into two parts using " to " as the delimiter.
-// If the value does not contain exactly two parts, an error is returned.
-// The field is then appended to the fields slice in the Options struct.
+// If the operator is "range", the value is parsed into the bounds of its ranges, see parseRanges.
+// If the operator is "anyof" or "allof", the value is parsed into its elements, see parseElements.
+// If the operator is "words", the value is split into its words, each one matched like the "like" operator.
+// Patterns starting with a wildcard may be rejected, see WithoutLeadingWildcards and WithFullTextRewrite.
+// If the operator is "has" or "hasnot", the value must name a relation registered on the parser,
+// and if it is "in_sub" or "notin_sub", a subquery registered on the parser.
+// If the field is a count filter, its values must be integers.
+// Duplicate conditions are handled according to the duplicate policy of the parser, see WithDuplicatePolicy.
 // Returns nil if successful, otherwise returns an error.
-func (o *Options) AddField(name, value, operator string) error {
-	if err := validateOperator(operator); err != nil {
+func (o *Options) addField(field *Field) error {
+	if err := validateOperator(field.Operator); err != nil {
 		return err
 	}
 
-	if operator == sqlOperatorLike && !strings.ContainsAny(value, "%") {
-		value = fmt.Sprintf("%%%s%%", value)
+	if o.config != nil && o.config.policy != nil && !o.config.policy.Allow(o.user, field.Name, revertOperator(field.Operator)) {
+		return fmt.Errorf("filtering on %s with operator %s is not allowed", field.Name, revertOperator(field.Operator))
 	}
 
-	if operator == sqlOperatorRange {
-		args := strings.Split(value, " to ")
-		if len(args) != 2 {
-			return fmt.Errorf("invalid usage of operator rng. rng:value1:to:value2")
+	if err := o.authorizeField(field); err != nil {
+		return err
+	}
+
+	if o.config != nil && field.Class != "" {
+		if hook, ok := o.config.classifications[field.Class]; ok {
+			if err := hook(o.user, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	encrypt, err := o.encrypter(field)
+	if err != nil {
+		return err
+	}
+
+	if field.Operator == sqlOperatorPeriod {
+		if _, _, err := o.period(field); err != nil {
+			return err
 		}
+	}
 
-		value = fmt.Sprintf("%s %s", args[0], args[1])
+	if field.Operator == sqlOperatorLike && !strings.ContainsAny(field.Value, "%") {
+		field.Value = fmt.Sprintf("%%%s%%", field.Value)
 	}
 
-	o.fields = append(o.fields, &Field{
-		Name:     name,
-		Value:    value,
-		Operator: operator,
+	if field.Operator == sqlOperatorRange {
+		bounds, err := parseRanges(field.Value)
+		if err != nil {
+			return err
+		}
+
+		field.Values = bounds
+	}
+
+	if field.Operator == sqlOperatorAnyOf || field.Operator == sqlOperatorAllOf {
+		elements, err := parseElements(field.Value)
+		if err != nil {
+			return err
+		}
+
+		field.Values = elements
+	}
+
+	if field.Operator == sqlOperatorWords {
+		words := strings.Fields(field.Value)
+		if len(words) == 0 {
+			return fmt.Errorf("words of %s must not be empty", field.Name)
+		}
+
+		for i, word := range words {
+			if !strings.ContainsAny(word, "%") {
+				words[i] = fmt.Sprintf("%%%s%%", word)
+			}
+		}
+
+		field.Values = words
+	}
+
+	if err := o.checkLeadingWildcard(field); err != nil {
+		return err
+	}
+
+	if encrypt != nil && field.Operator != sqlOperatorInSubquery && field.Operator != sqlOperatorNotInSubquery {
+		if err := encryptField(field, encrypt); err != nil {
+			return err
+		}
+	}
+
+	if field.Operator == sqlOperatorExists || field.Operator == sqlOperatorNotExists {
+		if _, ok := o.relation(field.Value); !ok {
+			return fmt.Errorf("unknown relation %s", field.Value)
+		}
+	}
+
+	if field.Operator == sqlOperatorInSubquery || field.Operator == sqlOperatorNotInSubquery {
+		if _, ok := o.subquery(field.Value); !ok {
+			return fmt.Errorf("unknown subquery %s", field.Value)
+		}
+	}
+
+	if _, ok := o.countFilter(field); ok {
+		values := field.Values
+		if field.Operator != sqlOperatorRange {
+			values = []string{field.Value}
+		}
+
+		for _, value := range values {
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return fmt.Errorf("count filter %s requires integer values", field.Name)
+			}
+		}
+	}
+
+	return o.appendField(field)
+}
+
+// column returns the SQL expression the given field is compared against.
+// Fields registered as count filters on the parser are replaced by their counting subquery,
+// every other field is compared against the column named after it, qualified by its table if set,
+// or against the column of the view the options are applied to, see WithView.
+func (o *Options) column(field *Field) string {
+	if relation, ok := o.countFilter(field); ok {
+		return relation.countQuery()
+	}
+
+	if view := o.view(); view != nil {
+		if field.Table != "" {
+			if mapped, ok := view.Columns[field.Table+"."+field.Name]; ok {
+				return mapped
+			}
+		}
+
+		return o.viewColumn(field.Name)
+	}
+
+	if field.Table != "" {
+		return field.Table + "." + field.Name
+	}
+
+	return field.Name
+}
+
+// joins returns the join clauses registered for the tables of the fields.
+// Every join is returned once, in the order its table first appears in the fields.
+// No joins are returned when the options are applied to a view, which already joins the tables.
+func (o *Options) joins() []string {
+	if o.config == nil || o.config.view != nil {
+		return nil
+	}
+
+	joins := make([]string, 0)
+	seen := make(map[string]struct{})
+
+	for _, field := range o.fields {
+		join, ok := o.config.joins[field.Table]
+		if !ok {
+			continue
+		}
+
+		if _, ok := seen[field.Table]; ok {
+			continue
+		}
+
+		seen[field.Table] = struct{}{}
+		joins = append(joins, join)
+	}
+
+	return joins
+}
+
+// relation returns the relation registered on the parser under the given name.
+func (o *Options) relation(name string) (Relation, bool) {
+	if o.config == nil {
+		return Relation{}, false
+	}
+
+	relation, ok := o.config.relations[name]
+
+	return relation, ok
+}
+
+// conditions converts the fields of the Options struct into SQL conditions.
+// Each condition holds a query with "?" placeholders and the arguments bound to them.
+// The fields are built as a boolean tree combined with AND, see fieldNode, which is normalized and
+// rendered as one condition per child of its root, see node.normalize and node.render,
+// so nested conditions of the same kind are flattened, e.g. the words of a "words" filter
+// render "name ILIKE ? AND name ILIKE ?" among the other conditions, and only operators of another kind
+// are wrapped in parentheses, e.g. the ranges of a "range" filter.
+func (o *Options) conditions(dialect Dialect) []condition {
+	tree := o.conditionTree(dialect)
+	if tree.kind != nodeAnd {
+		return []condition{tree.render(nodeAnd)}
+	}
+
+	conditions := make([]condition, 0, len(tree.children))
+	for _, child := range tree.children {
+		conditions = append(conditions, child.render(nodeAnd))
+	}
+
+	return conditions
+}
+
+// fieldNode builds the given field as a boolean tree for the given dialect.
+// The "period" operator binds the bounds of the current period, see periodCondition,
+// the "range" operator binds the bounds of each of its ranges, combining several ranges with OR,
+// the "has" and "hasnot" operators render
+// an existence subquery for the relation named by the value, "hasnot" negating it, the "in_sub" and "notin_sub" operators
+// compare the column against the subquery named by the value, see WithSubquery, the "anyof" and "allof" operators
+// render a JSON array membership test, see arrayCondition, the "words" operator matches every word
+// like the "like" operator, combining them with AND, and every other operator binds a single value.
+// Patterns starting with a wildcard are rendered as full text conditions if the parser rewrites them, see WithFullTextRewrite.
+// Folded fields compare LOWER of the column with LOWER of the pattern for the "like" and "words" operators,
+// so functional indexes on LOWER(column) can be used instead of ILIKE.
+// Operators are rendered in the form understood by the given dialect.
+func (o *Options) fieldNode(dialect Dialect, field *Field) node {
+	operator := dialectOperator(dialect, field.Operator)
+	column := o.column(field)
+
+	switch field.Operator {
+	case sqlOperatorExists, sqlOperatorNotExists:
+		relation, _ := o.relation(field.Value)
+
+		exists := negatableLeaf(
+			condition{query: fmt.Sprintf("%s %s", sqlOperatorExists, relation.existsQuery())},
+			condition{query: fmt.Sprintf("%s %s", sqlOperatorNotExists, relation.existsQuery())},
+		)
+
+		if field.Operator == sqlOperatorNotExists {
+			return not(exists)
+		}
+
+		return exists
+	case sqlOperatorInSubquery, sqlOperatorNotInSubquery:
+		return leaf(o.subqueryCondition(column, field))
+	case sqlOperatorPeriod:
+		return leaf(o.periodCondition(column, field))
+	case sqlOperatorRange:
+		ranges := make([]node, 0, len(field.Values)/2)
+
+		for i := 0; i+1 < len(field.Values); i += 2 {
+			ranges = append(ranges, leaf(condition{
+				query: fmt.Sprintf("%s %s ? AND ?", column, operator),
+				args:  []interface{}{o.bind(field, field.Values[i]), o.bind(field, field.Values[i+1])},
+			}))
+		}
+
+		return or(ranges...)
+	case sqlOperatorAnyOf, sqlOperatorAllOf:
+		return leaf(arrayCondition(dialect, column, field))
+	}
+
+	if n, ok := o.fullTextNode(dialect, column, field); ok {
+		return n
+	}
+
+	if field.Operator == sqlOperatorWords {
+		return wordsNode(dialect, column, field)
+	}
+
+	if field.Operator == sqlOperatorLike && field.Fold {
+		return leaf(condition{
+			query: fmt.Sprintf("LOWER(%s) LIKE LOWER(?)", column),
+			args:  []interface{}{field.Value},
+		})
+	}
+
+	return leaf(condition{
+		query: fmt.Sprintf("%s %s ?", column, operator),
+		args:  []interface{}{o.bind(field, field.Value)},
 	})
+}
 
-	return nil
+// bind returns the argument bound for the given value of the field.
+// Count filters bind integers, since some databases compare a count with a string argument as text,
+// every other field binds the value as is.
+func (o *Options) bind(field *Field, value string) interface{} {
+	if _, ok := o.countFilter(field); ok {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	}
+
+	return value
+}
+
+// countFilter returns the relation counted by the given field if it is registered as a count filter.
+func (o *Options) countFilter(field *Field) (Relation, bool) {
+	if o.config == nil {
+		return Relation{}, false
+	}
+
+	relation, ok := o.config.countFilters[field.Name]
+
+	return relation, ok
 }
 
 // Apply applies the options to the given GORM transaction.
-// It iterates through each option and applies the corresponding condition to the transaction.
-// If the option's operator is "range", it splits the option value by space and applies a range condition.
-// Otherwise, it applies a regular condition using the option's name, operator, and value.
-// It also sets the offset and limit of the transaction based on the options.
+// It applies the filters of the options, see filter, selects the requested columns
+// and orders the transaction by the sort columns.
+// The associations included by the options are preloaded with their filters, see WithInclude.
+// It also sets the offset and limit of the transaction based on the options, see paginate.
 // Finally, it returns the modified transaction.
+// Apply is read-only by construction: it never executes statements and only adds clauses of a SELECT,
+// so the transaction has no side effects unless the caller runs a write on it, see qparsertest.AssertReadOnly.
 func (o *Options) Apply(tx *gorm.DB) *gorm.DB {
-	for _, option := range o.fields {
-		if option.Operator == sqlOperatorRange {
-			args := strings.Split(option.Value, " ")
+	tx = o.filter(tx)
 
-			tx = tx.Where(fmt.Sprintf("%s %s ? AND ?", option.Name, option.Operator), args[0], args[1])
-			continue
-		}
+	if len(o.selects) > 0 {
+		tx = tx.Select(o.viewSelects(o.selects))
+	}
+
+	tx = o.preload(tx)
+
+	for _, sort := range o.orderBy() {
+		tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: o.viewColumn(sort.Column)}, Desc: sort.Desc})
+	}
+
+	return o.paginate(tx)
+}
+
+// filter applies the filters of the options to the given GORM transaction, without sorting or pagination.
+// It iterates through each condition built from the options for the dialect of the transaction
+// and applies it to the transaction.
+// The joins of the tables used by the fields are added once each.
+// If the parser has a scope provider, the mandatory scope of the user is applied to the transaction right away,
+// so its conditions are part of the returned instance like the other ones.
+// If the parser routes to replicas, the transaction is marked for replica reads, unless Primary was used.
+// If the parser has a view, the transaction selects from the view, see WithView.
+// If the parser has a collision handler, the conditions already present on the transaction are inspected first,
+// and the error of the handler is added to the transaction, see WithCollisionHandler.
+// If the parser drops conditions on missing columns, they are checked before the conditions are built,
+// and a mandatory condition on a missing column is added to the transaction as an error, see WithMissingColumns.
+func (o *Options) filter(tx *gorm.DB) *gorm.DB {
+	if err := o.checkCollisions(tx); err != nil {
+		tx = tx.Clauses()
+		tx.AddError(err)
+	}
 
-		tx = tx.Where(fmt.Sprintf("%s %s ?", option.Name, option.Operator), option.Value)
+	if view := o.view(); view != nil {
+		tx = tx.Table(view.Name)
 	}
 
-	tx = tx.Offset(o.offset)
+	tx = o.scopeDeleted(tx)
 
-	if o.limit > 0 {
-		tx = tx.Limit(o.limit)
+	if o.primary {
+		tx = tx.Clauses(dbresolver.Write)
+	} else if o.config != nil && o.config.replicaRouting {
+		tx = tx.Clauses(dbresolver.Read)
 	}
 
-	tx = tx.Offset(o.offset)
+	present, err := o.present(tx)
+	if err != nil {
+		tx = tx.Clauses()
+		tx.AddError(err)
+
+		present = o
+	}
+
+	for _, join := range present.joins() {
+		tx = tx.Joins(join)
+	}
+
+	for _, c := range present.conditions(dialectOf(tx)) {
+		tx = tx.Where(c.expression())
+	}
+
+	if o.config != nil && o.config.scope != nil {
+		if scope := o.config.scope.Scope(o.user); scope != nil {
+			tx = scope(tx)
+		}
+	}
 
 	return tx
 }
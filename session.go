@@ -0,0 +1,54 @@
+package qparser
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// SessionSettings returns the postgres session settings of a user, e.g. {"app.tenant_id": "42"},
+// read by row-level security policies of the queried tables.
+type SessionSettings func(user interface{}) map[string]string
+
+// WithSessionSettings configures the parser to set the session settings of the user
+// before the query is executed by Options.Transaction.
+func WithSessionSettings(settings SessionSettings) ParserOption {
+	return func(c *config) {
+		c.sessionSettings = settings
+	}
+}
+
+// Transaction runs fn inside a transaction with the query built by Apply.
+// Before fn is called, the session settings of the user are set for the transaction only,
+// which is the equivalent of SET LOCAL, so row-level security policies see them.
+// Settings are set with set_config, so their names and values are bound as arguments.
+// Session settings are supported for postgres only.
+// If fn returns an error, the transaction is rolled back and the error is returned.
+func (o *Options) Transaction(tx *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var settings map[string]string
+	if o.config != nil && o.config.sessionSettings != nil {
+		settings = o.config.sessionSettings(o.user)
+	}
+
+	if len(settings) > 0 && dialectOf(tx) != DialectPostgres {
+		return fmt.Errorf("session settings are not supported for dialect %q", dialectOf(tx))
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return tx.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			if err := tx.Exec("SELECT set_config(?, ?, true)", name, settings[name]).Error; err != nil {
+				return fmt.Errorf("failed to set %s: %w", name, err)
+			}
+		}
+
+		return fn(o.Apply(tx))
+	})
+}
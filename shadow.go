@@ -0,0 +1,93 @@
+package qparser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Divergence describes a query parsed differently by the primary and the shadow parser of a Shadow.
+// Input is the parsed url.Values or struct, and the options are nil when the corresponding parse failed.
+type Divergence struct {
+	Input        interface{}
+	Primary      *Options
+	PrimaryError error
+	Shadow       *Options
+	ShadowError  error
+}
+
+// DivergenceReporter is notified with every divergence found by a Shadow.
+type DivergenceReporter func(ctx context.Context, divergence Divergence)
+
+// Shadow parses queries with a primary parser and, in the background, with a shadow parser,
+// e.g. configured with a new grammar or schema, reporting every divergence between their results.
+// Callers only ever see the results of the primary parser, so a new grammar can be rolled out on live traffic
+// without affecting responses.
+type Shadow struct {
+	primary *Parser
+	shadow  *Parser
+	report  DivergenceReporter
+}
+
+// NewShadow creates a new Shadow comparing the given parsers and reporting divergences to the given reporter.
+func NewShadow(primary, shadow *Parser, report DivergenceReporter) *Shadow {
+	return &Shadow{
+		primary: primary,
+		shadow:  shadow,
+		report:  report,
+	}
+}
+
+// ParseValuesContext parses the given query values with both parsers and returns the result of the primary parser.
+func (s *Shadow) ParseValuesContext(ctx context.Context, values url.Values) (*Options, error) {
+	options, err := s.primary.ParseValuesContext(ctx, values)
+
+	go s.compare(context.WithoutCancel(ctx), values, options, err, func(ctx context.Context) (*Options, error) {
+		return s.shadow.ParseValuesContext(ctx, values)
+	})
+
+	return options, err
+}
+
+// ParseStructContext parses the given data with both parsers and returns the result of the primary parser.
+func (s *Shadow) ParseStructContext(ctx context.Context, data interface{}) (*Options, error) {
+	options, err := s.primary.ParseStructContext(ctx, data)
+
+	go s.compare(context.WithoutCancel(ctx), data, options, err, func(ctx context.Context) (*Options, error) {
+		return s.shadow.ParseStructContext(ctx, data)
+	})
+
+	return options, err
+}
+
+// compare parses the input with the shadow parser and reports a divergence from the primary result.
+// The results diverge when only one of them failed, or when both succeeded with different hashes, see Options.Hash.
+// The context is detached from the request, so the shadow parse is neither canceled nor limited by its deadline,
+// and a panic of the shadow parser is reported as its error.
+func (s *Shadow) compare(ctx context.Context, input interface{}, primary *Options, primaryErr error, parse func(context.Context) (*Options, error)) {
+	shadow, shadowErr := func() (options *Options, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("shadow parser panicked: %v", r)
+			}
+		}()
+
+		return parse(ctx)
+	}()
+
+	if primaryErr != nil && shadowErr != nil {
+		return
+	}
+
+	if primaryErr == nil && shadowErr == nil && primary.Hash() == shadow.Hash() {
+		return
+	}
+
+	s.report(ctx, Divergence{
+		Input:        input,
+		Primary:      primary,
+		PrimaryError: primaryErr,
+		Shadow:       shadow,
+		ShadowError:  shadowErr,
+	})
+}
@@ -0,0 +1,32 @@
+package qparser
+
+import (
+	"golang.org/x/sync/singleflight"
+)
+
+// Group deduplicates concurrent identical queries, e.g. dashboards auto-refreshing the same list.
+// Calls with the same key and options by the same user share a single execution and its result.
+// The zero value is ready to use.
+type Group struct {
+	group singleflight.Group
+}
+
+// Do executes fn for the given options, unless an execution for the same key, options and user is already
+// in flight, in which case it waits for that execution and returns its result instead.
+// The key scopes the deduplication, it usually names the endpoint or model. The user the options were parsed for,
+// see ContextWithUser, is part of the deduplication, so callers never share results filtered by per-user scopes.
+// shared reports whether the result was given to several callers, in which case it must be treated as read-only.
+func (g *Group) Do(key string, opts *Options, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	return g.group.Do(flightKey(key, opts), fn)
+}
+
+// Forget makes the group forget the execution in flight for the given key, options and user,
+// so the next call executes fn again instead of waiting for it.
+func (g *Group) Forget(key string, opts *Options) {
+	g.group.Forget(flightKey(key, opts))
+}
+
+// flightKey returns the key of the execution of the given options in flight under the given key.
+func flightKey(key string, opts *Options) string {
+	return key + ":" + opts.Hash() + ":" + opts.principal()
+}
@@ -0,0 +1,24 @@
+package qparser
+
+import "gorm.io/gorm"
+
+// IncludeDeleted returns a copy of the options including the rows soft-deleted with gorm.DeletedAt,
+// e.g. for admin views of archived records.
+// Soft-deleted rows are excluded by default, and Apply, Count, EstimateCount, FindWithCount, Breakdown
+// and Union all follow the same setting, so counts and summaries never disagree with the list they accompany.
+func (o *Options) IncludeDeleted() *Options {
+	opt := *o
+	opt.unscoped = true
+	opt.borrowed = true
+
+	return &opt
+}
+
+// scopeDeleted applies the soft-delete setting of the options to the given GORM transaction.
+func (o *Options) scopeDeleted(tx *gorm.DB) *gorm.DB {
+	if o.unscoped {
+		return tx.Unscoped()
+	}
+
+	return tx
+}
@@ -0,0 +1,241 @@
+package qparser
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// validateDialect validates the given dialect.
+// It checks if the dialect is one of the supported SQL dialects.
+// If the dialect is not supported, it returns an error.
+func validateDialect(dialect Dialect) error {
+	switch dialect {
+	case DialectPostgres:
+	case DialectMySQL:
+	case DialectSQLite:
+	default:
+		return fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	return nil
+}
+
+// dialectOperator converts the given SQL operator to the form understood by the dialect.
+// ILIKE only exists in postgres, so mysql and sqlite fall back to LIKE,
+// which is case-insensitive there with the default collations.
+func dialectOperator(dialect Dialect, operator string) string {
+	if operator != sqlOperatorLike {
+		return operator
+	}
+
+	switch dialect {
+	case DialectMySQL, DialectSQLite:
+		return "LIKE"
+	default:
+		return operator
+	}
+}
+
+// dialectOf returns the dialect of the given GORM transaction.
+func dialectOf(tx *gorm.DB) Dialect {
+	if tx.Dialector == nil {
+		return ""
+	}
+
+	return Dialect(tx.Dialector.Name())
+}
+
+// PlaceholderStyle is the style of the placeholders rendered by Options.ToSQLWithPlaceholders.
+type PlaceholderStyle string
+
+const (
+	// PlaceholderQuestion renders "?" placeholders, e.g. for database/sql with the mysql and sqlite drivers.
+	PlaceholderQuestion PlaceholderStyle = "?"
+	// PlaceholderDollar renders numbered "$1" to "$n" placeholders, e.g. for pgx and lib/pq.
+	PlaceholderDollar PlaceholderStyle = "$"
+	// PlaceholderNamed renders named ":p1" to ":pn" placeholders and returns the arguments as sql.NamedArg,
+	// e.g. for named-parameter libraries.
+	PlaceholderNamed PlaceholderStyle = ":"
+)
+
+// ToSQL renders the options as a plain SQL fragment for the given dialect.
+// The fragment contains the JOIN, WHERE, ORDER BY, LIMIT and OFFSET clauses with "?" placeholders,
+// and the returned arguments are bound to the placeholders in order.
+// Literal question marks of operators like the postgres ?| are kept as is.
+// An empty fragment is returned when the options contain neither fields nor pagination.
+// If the dialect is not supported, an error is returned.
+func (o *Options) ToSQL(dialect Dialect) (string, []interface{}, error) {
+	return o.ToSQLWithPlaceholders(dialect, PlaceholderQuestion)
+}
+
+// ToSQLWithPlaceholders renders the options as a plain SQL fragment for the given dialect like ToSQL,
+// with placeholders of the given style, so the fragment is usable as is with the driver or library executing it.
+// Literal question marks of operators like the postgres ?| are never mistaken for placeholders.
+// If the dialect or the placeholder style is not supported, an error is returned.
+func (o *Options) ToSQLWithPlaceholders(dialect Dialect, style PlaceholderStyle) (string, []interface{}, error) {
+	switch style {
+	case PlaceholderQuestion, PlaceholderDollar, PlaceholderNamed:
+	default:
+		return "", nil, fmt.Errorf("unsupported placeholder style %q", style)
+	}
+
+	query, args, err := o.sql(dialect)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if style == PlaceholderQuestion {
+		return unescape(query), args, nil
+	}
+
+	var b strings.Builder
+
+	n := 0
+
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' && i+1 < len(query) && query[i+1] == '?' {
+			b.WriteByte('?')
+			i++
+
+			continue
+		}
+
+		if query[i] == '?' {
+			n++
+
+			if style == PlaceholderDollar {
+				fmt.Fprintf(&b, "$%d", n)
+			} else {
+				fmt.Fprintf(&b, ":p%d", n)
+			}
+
+			continue
+		}
+
+		b.WriteByte(query[i])
+	}
+
+	if style == PlaceholderNamed {
+		named := make([]interface{}, 0, len(args))
+		for i, arg := range args {
+			named = append(named, sql.Named(fmt.Sprintf("p%d", i+1), arg))
+		}
+
+		args = named
+	}
+
+	return b.String(), args, nil
+}
+
+// sql renders the options as a plain SQL fragment for the given dialect like ToSQL,
+// with the literal question marks escaped as "??".
+func (o *Options) sql(dialect Dialect) (string, []interface{}, error) {
+	if err := validateDialect(dialect); err != nil {
+		return "", nil, err
+	}
+
+	parts := o.joins()
+	args := make([]interface{}, 0, len(o.fields))
+
+	conditions := append(o.conditions(dialect), o.cursorConditions(dialect)...)
+	if len(conditions) > 0 {
+		queries := make([]string, 0, len(conditions))
+
+		for _, c := range conditions {
+			queries = append(queries, c.query)
+			args = append(args, c.args...)
+		}
+
+		parts = append(parts, "WHERE "+strings.Join(queries, " AND "))
+	}
+
+	if sorts := o.orderBy(); len(sorts) > 0 {
+		columns := make([]string, 0, len(sorts))
+
+		for _, sort := range sorts {
+			if sort.Desc {
+				columns = append(columns, o.viewColumn(sort.Column)+" DESC")
+				continue
+			}
+
+			columns = append(columns, o.viewColumn(sort.Column))
+		}
+
+		parts = append(parts, "ORDER BY "+strings.Join(columns, ", "))
+	}
+
+	if o.limit > 0 {
+		parts = append(parts, fmt.Sprintf("LIMIT %d", o.limit))
+	}
+
+	if o.offset > 0 && o.since == nil && o.after == nil {
+		parts = append(parts, fmt.Sprintf("OFFSET %d", o.offset))
+	}
+
+	return strings.Join(parts, " "), args, nil
+}
+
+// InterpolatedSQL renders the options as a SQL fragment with every argument inlined.
+// Values are quoted and escaped for the given dialect, so the output can be pasted
+// into a database console when reproducing an issue.
+//
+// DEBUG ONLY: the output must never be executed by the application, use Apply or ToSQL instead.
+func (o *Options) InterpolatedSQL(dialect Dialect) (string, error) {
+	query, args, err := o.sql(dialect)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' && i+1 < len(query) && query[i+1] == '?' {
+			b.WriteByte('?')
+			i++
+
+			continue
+		}
+
+		if query[i] == '?' && len(args) > 0 {
+			b.WriteString(quoteValue(dialect, args[0]))
+			args = args[1:]
+
+			continue
+		}
+
+		b.WriteByte(query[i])
+	}
+
+	return b.String(), nil
+}
+
+// quoteValue renders the given argument as a SQL literal for the given dialect.
+// Strings are wrapped in single quotes with embedded quotes doubled,
+// mysql additionally escapes backslashes because they are escape characters there.
+func quoteValue(dialect Dialect, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case time.Time:
+		return quoteString(dialect, v.Format(time.RFC3339Nano))
+	default:
+		return quoteString(dialect, fmt.Sprint(v))
+	}
+}
+
+// quoteString wraps the given string in single quotes, escaping it for the given dialect.
+func quoteString(dialect Dialect, value string) string {
+	if dialect == DialectMySQL {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
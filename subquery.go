@@ -0,0 +1,53 @@
+package qparser
+
+import "fmt"
+
+// Subquery is a server-side subquery usable with the "in_sub" and "notin_sub" operators, see WithSubquery.
+// Query selects the single column the filtered column is compared against,
+// e.g. "SELECT blocked_id FROM blocks WHERE blocker_id = ?".
+// Args returns the arguments bound to the placeholders of the query for the user the options were parsed for,
+// see ContextWithUser, and may be nil for queries without placeholders.
+// Tables are the tables the query reads from, e.g. "blocks", with which the results of CachedFind are tagged.
+type Subquery struct {
+	Query  string
+	Args   func(user interface{}) []interface{}
+	Tables []string
+}
+
+// WithSubquery registers a named subquery usable with the "in_sub" and "notin_sub" operators.
+// A query like "id=notin_sub:blocked_users" then renders "id NOT IN (SELECT ...)" with the registered subquery,
+// so blocklists are excluded without clients ever providing SQL. The value only names the subquery,
+// unknown names are rejected. Subqueries used with "notin_sub" must not select NULL values,
+// which make NOT IN match no rows.
+func WithSubquery(name string, subquery Subquery) ParserOption {
+	return func(c *config) {
+		c.subqueries[name] = subquery
+	}
+}
+
+// subquery returns the subquery registered on the parser under the given name.
+func (o *Options) subquery(name string) (Subquery, bool) {
+	if o.config == nil {
+		return Subquery{}, false
+	}
+
+	subquery, ok := o.config.subqueries[name]
+
+	return subquery, ok
+}
+
+// subqueryCondition returns the condition comparing the given column against the subquery named by the field,
+// binding the arguments of the subquery for the user of the options.
+func (o *Options) subqueryCondition(column string, field *Field) condition {
+	subquery, _ := o.subquery(field.Value)
+
+	var args []interface{}
+	if subquery.Args != nil {
+		args = subquery.Args(o.user)
+	}
+
+	return condition{
+		query: fmt.Sprintf("%s %s (%s)", column, field.Operator, subquery.Query),
+		args:  args,
+	}
+}
@@ -0,0 +1,111 @@
+package qparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MatchHandler is notified with every row matching a subscription, with the ID of the subscription.
+type MatchHandler func(ctx context.Context, subscription string, row interface{})
+
+// Subscriptions holds options registered by users to be notified when rows matching them appear,
+// e.g. for "notify me when items matching my filter appear" features.
+// New or changed rows are evaluated in memory against every subscription, see Options.Match.
+// It is safe for concurrent use.
+type Subscriptions struct {
+	parser        *Parser
+	handler       MatchHandler
+	mu            sync.RWMutex
+	subscriptions map[string]*Options
+}
+
+// NewSubscriptions creates a new Subscriptions deserializing subscriptions with the given parser
+// and notifying the given handler of every match.
+func NewSubscriptions(parser *Parser, handler MatchHandler) *Subscriptions {
+	return &Subscriptions{
+		parser:        parser,
+		handler:       handler,
+		subscriptions: make(map[string]*Options),
+	}
+}
+
+// Subscribe registers the options serialized by Options.MarshalJSON under the given ID,
+// replacing the subscription with the same ID, if any.
+// The options are deserialized with the user and role stored in the context, see Parser.UnmarshalOptionsContext,
+// so the parser must have a schema, and must only use conditions which can be evaluated in memory, see Options.Match.
+// Filters on encrypted fields are rejected, since rows hold their plaintext while the options hold the ciphertext.
+func (s *Subscriptions) Subscribe(ctx context.Context, id string, data []byte) error {
+	options, err := s.parser.UnmarshalOptionsContext(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range options.fields {
+		if _, ok := options.countFilter(field); ok || field.Operator == sqlOperatorExists || field.Operator == sqlOperatorNotExists ||
+			field.Operator == sqlOperatorInSubquery || field.Operator == sqlOperatorNotInSubquery {
+			return fmt.Errorf("filter on %s cannot be matched in memory", field.Name)
+		}
+
+		if field.Encrypted {
+			return fmt.Errorf("encrypted filter on %s cannot be matched in memory", field.Name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscriptions[id] = options
+
+	return nil
+}
+
+// Unsubscribe removes the subscription with the given ID.
+func (s *Subscriptions) Unsubscribe(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, id)
+}
+
+// Evaluate matches every given new or changed row against every subscription,
+// and notifies the handler for each match, in the order of the rows and the IDs of the subscriptions.
+// Rows which cannot be matched against a subscription do not stop the evaluation,
+// their errors are returned joined once every row was evaluated.
+func (s *Subscriptions) Evaluate(ctx context.Context, rows ...interface{}) error {
+	s.mu.RLock()
+
+	ids := make([]string, 0, len(s.subscriptions))
+	for id := range s.subscriptions {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	subscriptions := make([]*Options, 0, len(ids))
+	for _, id := range ids {
+		subscriptions = append(subscriptions, s.subscriptions[id])
+	}
+
+	s.mu.RUnlock()
+
+	var errs []error
+
+	for _, row := range rows {
+		for i, options := range subscriptions {
+			matched, err := options.Match(row)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("subscription %s: %w", ids[i], err))
+				continue
+			}
+
+			if matched {
+				s.handler(ctx, ids[i], row)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
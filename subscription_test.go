@@ -0,0 +1,114 @@
+package qparser
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// subscriptionItem is a row evaluated against subscriptions.
+type subscriptionItem struct {
+	ID        uint
+	Email     string
+	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func subscriptionParser(c *clock) *Parser {
+	return NewParser(c.option(), WithEncryptedField("email", BlindIndex([]byte("key"))), WithSchema(&Schema{
+		Fields: []SchemaField{
+			{Name: "created_at", Operators: []string{"period"}},
+			{Name: "email", Operators: []string{"eq"}},
+		},
+	}))
+}
+
+func TestSubscriptionPeriodFollowsClock(t *testing.T) {
+	c := &clock{now: time.Date(2026, time.March, 4, 9, 0, 0, 0, time.UTC)}
+	p := subscriptionParser(c)
+
+	opts, err := p.ParseValues(url.Values{"created_at": {"period:this_week"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := opts.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var matched []uint
+
+	subscriptions := NewSubscriptions(p, func(_ context.Context, _ string, row interface{}) {
+		matched = append(matched, row.(*subscriptionItem).ID)
+	})
+
+	if err := subscriptions.Subscribe(context.Background(), "weekly", data); err != nil {
+		t.Fatal(err)
+	}
+
+	// two weeks later, only the items created during the current week match
+	c.now = time.Date(2026, time.March, 18, 9, 0, 0, 0, time.UTC)
+
+	items := []*subscriptionItem{
+		{ID: 1, CreatedAt: time.Date(2026, time.March, 4, 8, 0, 0, 0, time.UTC)},
+		{ID: 2, CreatedAt: time.Date(2026, time.March, 17, 8, 0, 0, 0, time.UTC)},
+		{ID: 3, CreatedAt: time.Date(2026, time.March, 18, 8, 0, 0, 0, time.UTC), DeletedAt: gorm.DeletedAt{Time: c.now, Valid: true}},
+	}
+
+	rows := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, item)
+	}
+
+	if err := subscriptions.Evaluate(context.Background(), rows...); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []uint{2}; !reflect.DeepEqual(matched, want) {
+		t.Fatalf("got %v, want %v", matched, want)
+	}
+}
+
+func TestSubscriptionIncludeDeleted(t *testing.T) {
+	c := &clock{now: time.Date(2026, time.March, 18, 9, 0, 0, 0, time.UTC)}
+
+	opts, err := subscriptionParser(c).ParseValues(url.Values{"created_at": {"period:today"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := &subscriptionItem{CreatedAt: c.now, DeletedAt: gorm.DeletedAt{Time: c.now, Valid: true}}
+
+	if matched, err := opts.Match(item); err != nil || matched {
+		t.Fatalf("soft-deleted row matched: %t %v", matched, err)
+	}
+
+	if matched, err := opts.IncludeDeleted().Match(item); err != nil || !matched {
+		t.Fatalf("soft-deleted row not matched with IncludeDeleted: %t %v", matched, err)
+	}
+}
+
+func TestSubscribeRejectsEncryptedFields(t *testing.T) {
+	p := subscriptionParser(&clock{now: time.Now()})
+
+	opts, err := p.ParseValues(url.Values{"email": {"eq:alice@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := opts.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subscriptions := NewSubscriptions(p, func(context.Context, string, interface{}) {})
+
+	if err := subscriptions.Subscribe(context.Background(), "encrypted", data); err == nil {
+		t.Fatal("subscription on an encrypted field was accepted")
+	}
+}
@@ -0,0 +1,216 @@
+package qparser
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tracer records the execution time of queries against their filter shape, see WithTracer and Options.Run.
+type Tracer interface {
+	Trace(shape string, duration time.Duration)
+}
+
+// TracerFunc is an adapter allowing the use of an ordinary function as a Tracer.
+type TracerFunc func(shape string, duration time.Duration)
+
+// Trace calls f(shape, duration).
+func (f TracerFunc) Trace(shape string, duration time.Duration) {
+	f(shape, duration)
+}
+
+// WithTracer configures the parser to report the execution time of the queries run through Options.Run
+// to the given tracer, e.g. a SlowShapes collecting the slowest filter combinations.
+func WithTracer(tracer Tracer) ParserOption {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
+
+// Shape returns the normalized shape of the options: the filtered fields with their operators, sorted by name,
+// followed by the sort columns, without the filtered values, e.g. "status=eq&total=gte&sort=-created_at".
+// Options filtering the same fields with the same operators and sorting by the same columns have the same shape,
+// which identifies the index a query of the shape needs.
+func (o *Options) Shape() string {
+	parts := make([]string, 0, len(o.fields)+1)
+
+	for _, field := range o.fields {
+		parts = append(parts, field.Name+"="+revertOperator(field.Operator))
+	}
+
+	sort.Strings(parts)
+
+	if len(o.sorts) > 0 {
+		columns := make([]string, 0, len(o.sorts))
+
+		for _, s := range o.sorts {
+			if s.Desc {
+				columns = append(columns, "-"+s.Column)
+				continue
+			}
+
+			columns = append(columns, s.Column)
+		}
+
+		parts = append(parts, "sort="+strings.Join(columns, ","))
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// Run applies the options to the given GORM transaction and executes the query with fn, e.g.
+//
+//	err := opts.Run(db.Model(&User{}), func(tx *gorm.DB) error {
+//		return tx.Find(&users).Error
+//	})
+//
+// The execution time of fn is reported to the tracer of the parser, including for failed queries,
+// with the shape of the options prefixed by the table of the transaction when it has a model or a table,
+// e.g. "users?status=eq&sort=-created_at", so the shapes of different endpoints are told apart.
+// Run returns the error of fn.
+func (o *Options) Run(tx *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if o.config == nil || o.config.tracer == nil {
+		return fn(o.Apply(tx))
+	}
+
+	shape := o.Shape()
+	if table, err := tableName(tx); err == nil {
+		shape = table + "?" + shape
+	}
+
+	tx = o.Apply(tx)
+
+	start := time.Now()
+	err := fn(tx)
+
+	o.config.tracer.Trace(shape, time.Since(start))
+
+	return err
+}
+
+// ShapeStats are the execution times recorded for a filter shape by SlowShapes.
+type ShapeStats struct {
+	Shape   string        `json:"shape"`
+	Count   int64         `json:"count"`
+	Total   time.Duration `json:"total"`
+	Average time.Duration `json:"average"`
+	Max     time.Duration `json:"max"`
+}
+
+// SlowShapes is a Tracer aggregating the execution times of every filter shape,
+// giving operators concrete targets for indexing. It is safe for concurrent use.
+type SlowShapes struct {
+	mu     sync.Mutex
+	limit  int
+	shapes map[string]*ShapeStats
+}
+
+// NewSlowShapes creates a new SlowShapes tracking at most the given number of distinct shapes,
+// 1000 by default when it is not positive. Once the limit is reached, the fastest shape is evicted for a new one.
+func NewSlowShapes(limit int) *SlowShapes {
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	return &SlowShapes{limit: limit, shapes: make(map[string]*ShapeStats)}
+}
+
+// Trace records the execution time of a query of the given shape.
+func (s *SlowShapes) Trace(shape string, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.shapes[shape]
+	if !ok {
+		if len(s.shapes) >= s.limit {
+			s.evict()
+		}
+
+		stats = &ShapeStats{Shape: shape}
+		s.shapes[shape] = stats
+	}
+
+	stats.Count++
+	stats.Total += duration
+	stats.Average = stats.Total / time.Duration(stats.Count)
+	stats.Max = max(stats.Max, duration)
+}
+
+// evict drops the shape with the lowest average execution time.
+func (s *SlowShapes) evict() {
+	var fastest *ShapeStats
+
+	for _, stats := range s.shapes {
+		if fastest == nil || stats.Average < fastest.Average {
+			fastest = stats
+		}
+	}
+
+	if fastest != nil {
+		delete(s.shapes, fastest.Shape)
+	}
+}
+
+// Top returns the statistics of the n shapes with the highest average execution time, slowest first.
+// A non-positive n returns every shape.
+func (s *SlowShapes) Top(n int) []ShapeStats {
+	s.mu.Lock()
+
+	top := make([]ShapeStats, 0, len(s.shapes))
+	for _, stats := range s.shapes {
+		top = append(top, *stats)
+	}
+
+	s.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Average != top[j].Average {
+			return top[i].Average > top[j].Average
+		}
+
+		return top[i].Shape < top[j].Shape
+	})
+
+	if n > 0 && n < len(top) {
+		top = top[:n]
+	}
+
+	return top
+}
+
+// Reset drops every recorded shape.
+func (s *SlowShapes) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shapes = make(map[string]*ShapeStats)
+}
+
+// Handler returns an introspection handler responding with the slowest shapes as JSON, slowest first.
+// The "n" query parameter sets the number of shapes returned, 10 by default and 0 for every shape.
+//
+// The shapes reveal the filterable fields of the endpoints and the handler must only be mounted for operators.
+func (s *SlowShapes) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 10
+
+		if value := r.URL.Query().Get("n"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				http.Error(w, "bad n, use a non-negative number", http.StatusBadRequest)
+				return
+			}
+
+			n = parsed
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Top(n))
+	})
+}
@@ -0,0 +1,45 @@
+package qparser
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UnionTarget describes a model searched by Options.Union.
+// Type is the discriminator returned in the "type" column for the rows of the model, bound as an argument,
+// and Columns are the columns selected from it, which must line up across all targets.
+type UnionTarget struct {
+	Type    string
+	Model   interface{}
+	Columns []string
+}
+
+// Union applies the options to several models at once for global search endpoints.
+// The filters of the options are applied to every target, and the rows of all targets
+// are combined with UNION ALL, each one carrying its target type in the "type" column.
+// Sorting and pagination are applied to the combined rows, so the sort columns must be
+// part of the selected columns.
+// The returned transaction can be executed with Find, e.g. into a slice of maps or of a result struct.
+func (o *Options) Union(tx *gorm.DB, targets ...UnionTarget) *gorm.DB {
+	queries := make([]string, 0, len(targets))
+	subqueries := make([]interface{}, 0, len(targets))
+
+	for _, target := range targets {
+		columns := append([]string{"? AS type"}, target.Columns...)
+
+		subquery := tx.Session(&gorm.Session{NewDB: true}).Model(target.Model).Select(strings.Join(columns, ", "), target.Type)
+
+		queries = append(queries, "?")
+		subqueries = append(subqueries, o.filter(subquery))
+	}
+
+	tx = tx.Table("("+strings.Join(queries, " UNION ALL ")+") AS results", subqueries...)
+
+	for _, sort := range o.orderBy() {
+		tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.Column}, Desc: sort.Desc})
+	}
+
+	return o.paginate(tx)
+}
@@ -0,0 +1,59 @@
+package qparser
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+type unionAccount struct {
+	ID   uint
+	Name string
+}
+
+func TestUnionBindsTypes(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		query string
+		args  []interface{}
+	)
+
+	if err := db.Callback().Query().After("gorm:query").Register("qparser:record", func(tx *gorm.DB) {
+		query, args = tx.Statement.SQL.String(), tx.Statement.Vars
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser(WithSchema(&Schema{Fields: []SchemaField{{Name: "name"}}}))
+
+	opts, err := p.ParseValues(url.Values{"name": {"eq:alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []map[string]interface{}
+
+	err = opts.Union(db.Session(&gorm.Session{DryRun: true}),
+		UnionTarget{Type: "user", Model: &genericUser{}, Columns: []string{"id", "name"}},
+		UnionTarget{Type: "o'brien", Model: &unionAccount{}, Columns: []string{"id", "name"}},
+	).Find(&results).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(query, "'user'") || strings.Contains(query, "brien") || strings.Count(query, "? AS type") != 2 {
+		t.Errorf("types are not bound: %q", query)
+	}
+
+	if want := []interface{}{"user", "alice", "o'brien", "alice"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got args %v, want %v", args, want)
+	}
+}
@@ -0,0 +1,109 @@
+package qparser
+
+// bound is a lower or upper bound of the values of a column.
+type bound struct {
+	value  string
+	strict bool
+	set    bool
+}
+
+// Unsatisfiable reports whether the filters of the options trivially contradict each other,
+// e.g. "age=gt:50" and "age=lt:10", so no row can match and callers can skip the database round trip.
+// The bounds set on each column by the comparison, "eq" and single "range" operators are intersected,
+// comparing values numerically if both are numbers and as instants if both are times, see orderValues.
+// Columns with bounds which cannot be ordered without knowing the column, e.g. strings whose order
+// depends on its collation, are never considered contradictory.
+// An "eq" and a "neq" condition with the same value on the same column are contradictory as well.
+// Other operators are never considered contradictory, so false does not mean that rows match.
+func (o *Options) Unsatisfiable() bool {
+	type column struct {
+		lower, upper bound
+		unordered    bool
+		equal        []string
+		notEqual     []string
+	}
+
+	columns := make(map[[2]string]*column)
+
+	for _, field := range o.fields {
+		key := [2]string{field.Table, field.Name}
+
+		c, ok := columns[key]
+		if !ok {
+			c = &column{}
+			columns[key] = c
+		}
+
+		var lower, upper bound
+
+		switch field.Operator {
+		case sqlOperatorGreaterThan, sqlOperatorGreaterThanEqual:
+			lower = bound{value: field.Value, strict: field.Operator == sqlOperatorGreaterThan, set: true}
+		case sqlOperatorLowerThan, sqlOperatorLowerThanEqual:
+			upper = bound{value: field.Value, strict: field.Operator == sqlOperatorLowerThan, set: true}
+		case sqlOperatorEqual:
+			lower = bound{value: field.Value, set: true}
+			upper = bound{value: field.Value, set: true}
+			c.equal = append(c.equal, field.Value)
+		case sqlOperatorNotEqual:
+			c.notEqual = append(c.notEqual, field.Value)
+		case sqlOperatorRange:
+			if len(field.Values) == 2 {
+				lower = bound{value: field.Values[0], set: true}
+				upper = bound{value: field.Values[1], set: true}
+			}
+		}
+
+		var lowerOrdered, upperOrdered bool
+
+		c.lower, lowerOrdered = tighter(c.lower, lower, 1)
+		c.upper, upperOrdered = tighter(c.upper, upper, -1)
+
+		if !lowerOrdered || !upperOrdered {
+			c.unordered = true
+		}
+	}
+
+	for _, c := range columns {
+		if c.lower.set && c.upper.set && !c.unordered {
+			cmp, ok := orderValues(c.lower.value, c.upper.value)
+			if ok && (cmp > 0 || cmp == 0 && (c.lower.strict || c.upper.strict)) {
+				return true
+			}
+		}
+
+		for _, value := range c.equal {
+			if contains(c.notEqual, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// tighter returns the tighter of the two bounds.
+// For lower bounds, direction is 1 and the greater value is tighter,
+// for upper bounds, direction is -1 and the lower value is tighter.
+// A strict bound is tighter than a non-strict one with the same value, and an unset bound is never tighter.
+// ordered is false if the values cannot be ordered, see orderValues, and the current bound is kept.
+func tighter(current, next bound, direction int) (tightest bound, ordered bool) {
+	if !next.set {
+		return current, true
+	}
+
+	if !current.set {
+		return next, true
+	}
+
+	cmp, ok := orderValues(next.value, current.value)
+	if !ok {
+		return current, false
+	}
+
+	if cmp*direction > 0 || cmp == 0 && next.strict {
+		return next, true
+	}
+
+	return current, true
+}
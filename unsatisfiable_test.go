@@ -0,0 +1,49 @@
+package qparser
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestUnsatisfiable(t *testing.T) {
+	p := NewParser(WithSchema(&Schema{
+		Fields: []SchemaField{{Name: "age"}, {Name: "name"}, {Name: "created_at"}},
+	}))
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "age=gt:50&age=lt:10", want: true},
+		{query: "age=gt:10&age=lt:50", want: false},
+		{query: "age=gte:10&age=lt:10", want: true},
+		{query: "age=eq:10&age=neq:10", want: true},
+		{query: "age=eq:10&age=eq:11", want: true},
+		{query: "age=rng:10 to 20&age=gt:30", want: true},
+		{query: "created_at=gte:2024-01-01T10:00:00%2B02:00&created_at=lt:2024-01-01T09:00:00Z", want: false},
+		{query: "created_at=gte:2024-01-01T10:00:00Z&created_at=lt:2024-01-01T11:00:00%2B02:00", want: true},
+		{query: "created_at=gte:2024-02-01&created_at=lt:2024-01-01", want: true},
+		{query: "created_at=gte:2024-02-01&created_at=lt:2024-01-01T00:00:00Z", want: false},
+		{query: "name=gt:b&name=lt:C", want: false},
+		{query: "name=eq:Bob&name=eq:bob", want: false},
+		{query: "name=eq:bob&name=neq:bob", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts, err := p.ParseValues(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := opts.Unsatisfiable(); got != tt.want {
+				t.Fatalf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package qparser
+
+// View describes a database view the options are applied to instead of the table of the model, see WithView.
+// Columns maps the logical field names of the public filter API to the columns of the view.
+// Fields owned by a table are mapped by their qualified name, e.g. "accounts.email",
+// and fields without a mapping are compared against the unqualified column of their name.
+type View struct {
+	Name    string
+	Columns map[string]string
+}
+
+// WithView configures the parser to apply the options to the given view, e.g. a view pushing heavy joins
+// and aggregates into the database, while keeping the same public filter API.
+// Filters, sort columns and selected columns are translated to the columns of the view, selected columns being
+// aliased back to their logical names so results are scanned as before, and the joins of the parser are not added.
+func WithView(view View) ParserOption {
+	return func(c *config) {
+		c.view = &view
+	}
+}
+
+// view returns the view the options are applied to, or nil if they are applied to the table of the model.
+func (o *Options) view() *View {
+	if o.config == nil {
+		return nil
+	}
+
+	return o.config.view
+}
+
+// viewColumn returns the column of the view for the given logical column, or the column itself without a view.
+func (o *Options) viewColumn(column string) string {
+	if view := o.view(); view != nil {
+		if mapped, ok := view.Columns[column]; ok {
+			return mapped
+		}
+	}
+
+	return column
+}
+
+// viewSelects returns the given selected columns translated to the columns of the view,
+// each remapped column being aliased back to its logical name.
+func (o *Options) viewSelects(columns []string) []string {
+	if o.view() == nil {
+		return columns
+	}
+
+	selects := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		if mapped := o.viewColumn(column); mapped != column {
+			selects = append(selects, mapped+" AS "+column)
+			continue
+		}
+
+		selects = append(selects, column)
+	}
+
+	return selects
+}
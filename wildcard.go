@@ -0,0 +1,122 @@
+package qparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrLeadingWildcard is returned when a pattern starting with a wildcard is rejected,
+// see WithoutLeadingWildcards and WithFullTextRewrite.
+var ErrLeadingWildcard = errors.New("patterns starting with a wildcard are not allowed")
+
+// leadingWildcards is the handling of patterns starting with a wildcard configured on the parser.
+type leadingWildcards struct {
+	reject  bool
+	dialect Dialect
+}
+
+// WithoutLeadingWildcards configures the parser to reject the patterns of the "like" and "words" operators
+// starting with a wildcard with ErrLeadingWildcard, since they cannot use a B-tree index and scan the whole table.
+// Values without a wildcard are wrapped in wildcards, so only prefix patterns like "like:john%" are accepted.
+func WithoutLeadingWildcards() ParserOption {
+	return func(c *config) {
+		c.leadingWildcards = &leadingWildcards{reject: true}
+	}
+}
+
+// WithFullTextRewrite configures the parser to rewrite the patterns of the "like" and "words" operators
+// starting with a wildcard into full text conditions of the given dialect, which full text indexes can serve.
+// On postgres the column is matched with to_tsvector and plainto_tsquery of the simple configuration,
+// and on mysql with MATCH AGAINST, which requires a FULLTEXT index on the column.
+// Full text conditions match whole words instead of substrings, so "like:john" matches "John Smith" but not "Johnson".
+// Patterns with wildcards inside the term cannot be rewritten and, like every leading wildcard pattern for dialects
+// without full text support, are rejected with ErrLeadingWildcard.
+// Options rendered for another dialect than the given one keep their patterns.
+func WithFullTextRewrite(dialect Dialect) ParserOption {
+	return func(c *config) {
+		c.leadingWildcards = &leadingWildcards{dialect: dialect}
+	}
+}
+
+// checkLeadingWildcard returns ErrLeadingWildcard if the field has a pattern starting with a wildcard
+// and the parser neither allows nor is able to rewrite it.
+func (o *Options) checkLeadingWildcard(field *Field) error {
+	if o.config == nil || o.config.leadingWildcards == nil {
+		return nil
+	}
+
+	wildcards := o.config.leadingWildcards
+
+	for _, pattern := range patterns(field) {
+		if !strings.HasPrefix(pattern, "%") {
+			continue
+		}
+
+		if wildcards.reject {
+			return fmt.Errorf("%w: %s of %s", ErrLeadingWildcard, pattern, field.Name)
+		}
+
+		switch wildcards.dialect {
+		case DialectPostgres, DialectMySQL:
+		default:
+			return fmt.Errorf("%w: dialect %q has no full text search", ErrLeadingWildcard, wildcards.dialect)
+		}
+
+		if term := strings.Trim(pattern, "%"); term == "" || strings.Contains(term, "%") {
+			return fmt.Errorf("%w: %s of %s cannot be rewritten to a full text search", ErrLeadingWildcard, pattern, field.Name)
+		}
+	}
+
+	return nil
+}
+
+// patterns returns the patterns of the field for the "like" and "words" operators, or nil.
+func patterns(field *Field) []string {
+	switch field.Operator {
+	case sqlOperatorLike:
+		return []string{field.Value}
+	case sqlOperatorWords:
+		return field.Values
+	default:
+		return nil
+	}
+}
+
+// fullTextNode builds the field as a tree of full text conditions for the given dialect
+// if its patterns start with a wildcard and the parser rewrites them for the dialect, see WithFullTextRewrite.
+// The words of the "words" operator are each matched, combined with AND.
+func (o *Options) fullTextNode(dialect Dialect, column string, field *Field) (node, bool) {
+	if o.config == nil || o.config.leadingWildcards == nil || o.config.leadingWildcards.dialect != dialect {
+		return node{}, false
+	}
+
+	fieldPatterns := patterns(field)
+
+	matches := make([]node, 0, len(fieldPatterns))
+
+	for _, pattern := range fieldPatterns {
+		if !strings.HasPrefix(pattern, "%") {
+			return node{}, false
+		}
+
+		var query string
+
+		switch dialect {
+		case DialectPostgres:
+			query = fmt.Sprintf("to_tsvector('simple', %s) @@ plainto_tsquery('simple', ?)", column)
+		case DialectMySQL:
+			query = fmt.Sprintf("MATCH (%s) AGAINST (?)", column)
+		default:
+			return node{}, false
+		}
+
+		matches = append(matches, leaf(condition{query: query, args: []interface{}{strings.Trim(pattern, "%")}}))
+	}
+
+	if len(matches) == 0 {
+		return node{}, false
+	}
+
+	return and(matches...), true
+}
@@ -0,0 +1,22 @@
+package qparser
+
+import "fmt"
+
+// wordsNode builds the "words" operator of the given field as the tree requiring every word
+// to match the column like the "like" operator, e.g. "name ILIKE ? AND name ILIKE ?",
+// so search boxes find rows containing all the words in any order.
+// Folded fields compare LOWER of the column with LOWER of every pattern instead.
+func wordsNode(dialect Dialect, column string, field *Field) node {
+	words := make([]node, 0, len(field.Values))
+
+	for _, word := range field.Values {
+		query := fmt.Sprintf("%s %s ?", column, dialectOperator(dialect, sqlOperatorLike))
+		if field.Fold {
+			query = fmt.Sprintf("LOWER(%s) LIKE LOWER(?)", column)
+		}
+
+		words = append(words, leaf(condition{query: query, args: []interface{}{word}}))
+	}
+
+	return and(words...)
+}